@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/njslxve/time-tracker-service/docs"
+	"github.com/njslxve/time-tracker-service/internal/auth"
+	"github.com/njslxve/time-tracker-service/internal/cache"
 	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/enrichment"
+	"github.com/njslxve/time-tracker-service/internal/policy"
 	"github.com/njslxve/time-tracker-service/internal/server"
 	"github.com/njslxve/time-tracker-service/internal/service"
 	"github.com/njslxve/time-tracker-service/internal/transport/api"
@@ -19,6 +26,14 @@ import (
 
 // @host localhost:8080
 // @BasePath /
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
 	logger := logger.New()
 	slog.SetDefault(logger)
@@ -32,7 +47,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	client, err := postgres.NewClient(cfg)
+	client, err := postgres.NewClient(context.Background(), cfg)
 	if err != nil {
 		slog.Debug("db error: ",
 			slog.String("error", err.Error()))
@@ -40,13 +55,45 @@ func main() {
 			slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	defer client.Close()
 
 	storage := storage.New(logger, client)
 	api := api.New(logger, cfg)
 
-	service := service.New(cfg, logger, storage, api)
+	backend, err := cache.NewBackend(cfg, storage)
+	if err != nil {
+		slog.Error("failed to set up info cache",
+			slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	cache := cache.New(logger, api, backend, cfg.InfoCacheTTL, cfg.InfoCacheNegativeTTL)
+
+	if cfg.CursorSecret == "" {
+		slog.Warn("CURSOR_SECRET is not set, page cursors will not survive a restart")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Sync looks for changed data, so it must not be answered from the cache.
+	service := service.New(ctx, cfg, logger, storage, api, cache)
+
+	verifier, err := auth.NewVerifier(cfg)
+	if err != nil {
+		slog.Error("failed to load jwt verification key",
+			slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	policy := policy.New(logger, storage)
+
+	server := server.New(cfg, logger, service, verifier, policy, cache)
 
-	server := server.New(cfg, logger, service)
+	workers := enrichment.New(logger, cfg, storage, cache)
+	go workers.Run(ctx)
+	go service.ScheduleSync(ctx)
+	go cache.ScheduleCleanup(ctx, cfg.InfoCacheCleanupInterval)
 
 	server.Start()
 }
@@ -0,0 +1,28 @@
+package audit
+
+import "context"
+
+const SystemActor = "system"
+
+// Actor identifies who caused a mutation and within which request. It is
+// carried in the context down to storage, which records it in the audit log.
+type Actor struct {
+	Subject   string
+	UserID    int
+	RequestID string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || actor.Subject == "" {
+		actor.Subject = SystemActor
+	}
+
+	return actor
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+type Principal struct {
+	Subject string
+	UserID  int
+	Method  string
+	Admin   bool
+	Role    string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+
+	return principal, ok
+}
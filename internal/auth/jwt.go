@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/config"
+)
+
+const leeway = 30 * time.Second
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	UserID    int      `json:"user_id"`
+	Admin     bool     `json:"admin"`
+}
+
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+}
+
+type Verifier struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	issuer    string
+	audience  string
+}
+
+func NewVerifier(cfg *config.Config) (*Verifier, error) {
+	v := &Verifier{
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+	}
+
+	if cfg.JWTSecret != "" {
+		v.secret = []byte(cfg.JWTSecret)
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+
+		v.publicKey = key
+	}
+
+	return v, nil
+}
+
+func (v *Verifier) Enabled() bool {
+	return v.secret != nil || v.publicKey != nil
+}
+
+func (v *Verifier) Verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Principal{}, ErrMalformedToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrMalformedToken
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch {
+	case h.Alg == "HS256" && v.secret != nil:
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write(signed)
+
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return Principal{}, ErrInvalidSignature
+		}
+	case h.Alg == "RS256" && v.publicKey != nil:
+		digest := sha256.Sum256(signed)
+
+		if err := rsa.VerifyPKCS1v15(v.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return Principal{}, ErrInvalidSignature
+		}
+	default:
+		return Principal{}, ErrUnsupportedAlg
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, ErrMalformedToken
+	}
+
+	if err := v.validate(claims, time.Now()); err != nil {
+		return Principal{}, err
+	}
+
+	principal := Principal{
+		Subject: claims.Subject,
+		UserID:  claims.UserID,
+		Method:  MethodJWT,
+		Admin:   claims.Admin,
+	}
+
+	if principal.UserID == 0 {
+		principal.UserID, _ = strconv.Atoi(claims.Subject)
+	}
+
+	return principal, nil
+}
+
+func (v *Verifier) validate(claims Claims, now time.Time) error {
+	if claims.ExpiresAt == nil || now.Add(-leeway).After(numericDate(*claims.ExpiresAt)) {
+		return ErrTokenExpired
+	}
+
+	if claims.NotBefore != nil && now.Add(leeway).Before(numericDate(*claims.NotBefore)) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
+
+func numericDate(value float64) time.Time {
+	return time.Unix(0, int64(value*float64(time.Second)))
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func parsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in public key")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, want RSA", key)
+		}
+
+		return rsaKey, nil
+	}
+
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		rsaKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("certificate key is %T, want RSA", cert.PublicKey)
+		}
+
+		return rsaKey, nil
+	}
+
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test secret")
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal error = %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+
+	signed := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("rsa.SignPKCS1v15 error = %v", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey error = %v", err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey error = %v", err)
+	}
+
+	now := time.Now()
+	exp := float64(now.Add(time.Hour).Unix())
+
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{"sub": "42", "exp": exp}
+		for k, v := range extra {
+			c[k] = v
+		}
+
+		return c
+	}
+
+	hmacVerifier := &Verifier{secret: testSecret}
+	rsaVerifier := &Verifier{publicKey: &key.PublicKey}
+	strictVerifier := &Verifier{secret: testSecret, issuer: "issuer", audience: "time-tracker"}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		want     Principal
+		wantErr  error
+	}{
+		{
+			name:     "hs256",
+			verifier: hmacVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"admin": true})),
+			want:     Principal{Subject: "42", UserID: 42, Method: MethodJWT, Admin: true},
+		},
+		{
+			name:     "user id claim wins over subject",
+			verifier: hmacVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"sub": "alice", "user_id": 7})),
+			want:     Principal{Subject: "alice", UserID: 7, Method: MethodJWT},
+		},
+		{
+			name:     "rs256",
+			verifier: rsaVerifier,
+			token:    signRS256(t, key, claims(nil)),
+			want:     Principal{Subject: "42", UserID: 42, Method: MethodJWT},
+		},
+		{
+			name:     "hs256 with wrong secret",
+			verifier: hmacVerifier,
+			token:    signHS256(t, []byte("other secret"), claims(nil)),
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "rs256 with wrong key",
+			verifier: rsaVerifier,
+			token:    signRS256(t, otherKey, claims(nil)),
+			wantErr:  ErrInvalidSignature,
+		},
+		{
+			name:     "rs256 without public key",
+			verifier: hmacVerifier,
+			token:    signRS256(t, key, claims(nil)),
+			wantErr:  ErrUnsupportedAlg,
+		},
+		{
+			name:     "hs256 without secret",
+			verifier: rsaVerifier,
+			token:    signHS256(t, testSecret, claims(nil)),
+			wantErr:  ErrUnsupportedAlg,
+		},
+		{
+			name:     "alg none",
+			verifier: hmacVerifier,
+			token:    encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, claims(nil)) + ".",
+			wantErr:  ErrUnsupportedAlg,
+		},
+		{
+			name:     "two segments",
+			verifier: hmacVerifier,
+			token:    "header.payload",
+			wantErr:  ErrMalformedToken,
+		},
+		{
+			name:     "header not base64",
+			verifier: hmacVerifier,
+			token:    "!!!.payload.signature",
+			wantErr:  ErrMalformedToken,
+		},
+		{
+			name:     "expired",
+			verifier: hmacVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"exp": float64(now.Add(-time.Minute).Unix())})),
+			wantErr:  ErrTokenExpired,
+		},
+		{
+			name:     "expired within leeway",
+			verifier: hmacVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"exp": float64(now.Add(-leeway / 2).Unix())})),
+			want:     Principal{Subject: "42", UserID: 42, Method: MethodJWT},
+		},
+		{
+			name:     "without expiry",
+			verifier: hmacVerifier,
+			token:    signHS256(t, testSecret, map[string]any{"sub": "42"}),
+			wantErr:  ErrTokenExpired,
+		},
+		{
+			name:     "not valid yet",
+			verifier: hmacVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"nbf": float64(now.Add(time.Minute).Unix())})),
+			wantErr:  ErrTokenNotYetValid,
+		},
+		{
+			name:     "issuer and audience match",
+			verifier: strictVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"iss": "issuer", "aud": []string{"other", "time-tracker"}})),
+			want:     Principal{Subject: "42", UserID: 42, Method: MethodJWT},
+		},
+		{
+			name:     "single audience",
+			verifier: strictVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"iss": "issuer", "aud": "time-tracker"})),
+			want:     Principal{Subject: "42", UserID: 42, Method: MethodJWT},
+		},
+		{
+			name:     "wrong issuer",
+			verifier: strictVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"iss": "other", "aud": "time-tracker"})),
+			wantErr:  ErrInvalidIssuer,
+		},
+		{
+			name:     "wrong audience",
+			verifier: strictVerifier,
+			token:    signHS256(t, testSecret, claims(map[string]any{"iss": "issuer", "aud": "other"})),
+			wantErr:  ErrInvalidAudience,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.verifier.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Verify = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey error = %v", err)
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("x509.MarshalPKIXPublicKey error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{
+			name: "pkix",
+			data: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}),
+		},
+		{
+			name: "pkcs1",
+			data: pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}),
+		},
+		{
+			name:    "not pem",
+			data:    []byte("not a key"),
+			wantErr: true,
+		},
+		{
+			name:    "garbage in pem",
+			data:    pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePublicKey(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePublicKey error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !got.Equal(&key.PublicKey) {
+				t.Errorf("parsePublicKey returned a different key")
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+	"github.com/njslxve/time-tracker-service/internal/service"
+	"github.com/njslxve/time-tracker-service/internal/transport/api"
+)
+
+// Backend stores cache entries. Get reports a miss with false rather than an
+// error; expired entries are misses.
+type Backend interface {
+	Name() string
+	Get(ctx context.Context, passport string) (entity.InfoCacheEntry, bool, error)
+	Set(ctx context.Context, entry entity.InfoCacheEntry) error
+	Delete(ctx context.Context, passport string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// Cache decorates a service.APIInterface, remembering answers for ttl and
+// passports the upstream does not know for negativeTTL. Backend failures are
+// logged and never fail a lookup.
+type Cache struct {
+	logger      *slog.Logger
+	next        service.APIInterface
+	backend     Backend
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	hits         atomic.Int64
+	negativeHits atomic.Int64
+	misses       atomic.Int64
+}
+
+func New(logger *slog.Logger, next service.APIInterface, backend Backend, ttl time.Duration, negativeTTL time.Duration) *Cache {
+	return &Cache{
+		logger:      logger,
+		next:        next,
+		backend:     backend,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+func (c *Cache) Info(ctx context.Context, value string) (dto.UserInfoResponse, error) {
+	const op = "cache.Cache.Info"
+
+	key, err := passport.Normalize(value)
+	if err != nil {
+		return c.next.Info(ctx, value)
+	}
+
+	entry, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		c.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	if ok {
+		if entry.NotFound {
+			c.negativeHits.Add(1)
+
+			return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, api.ErrNotFound)
+		}
+
+		c.hits.Add(1)
+
+		return dto.UserInfoResponse{
+			Name:       entry.Name,
+			Surname:    entry.Surname,
+			Patronymic: entry.Patronymic,
+			Adress:     entry.Adress,
+		}, nil
+	}
+
+	c.misses.Add(1)
+
+	userInfo, err := c.next.Info(ctx, value)
+
+	switch {
+	case err == nil:
+		entry = entity.InfoCacheEntry{
+			Passport:   key,
+			Name:       userInfo.Name,
+			Surname:    userInfo.Surname,
+			Patronymic: userInfo.Patronymic,
+			Adress:     userInfo.Adress,
+			ExpiresAt:  time.Now().Add(c.ttl),
+		}
+	case errors.Is(err, api.ErrNotFound) && c.negativeTTL > 0:
+		entry = entity.InfoCacheEntry{
+			Passport:  key,
+			NotFound:  true,
+			ExpiresAt: time.Now().Add(c.negativeTTL),
+		}
+	default:
+		return userInfo, err
+	}
+
+	if setErr := c.backend.Set(ctx, entry); setErr != nil {
+		c.logger.Error(op, slog.String("error", setErr.Error()))
+	}
+
+	return userInfo, err
+}
+
+// Evict forgets the entry for the passport value, so that nothing is kept
+// about a purged user.
+func (c *Cache) Evict(ctx context.Context, value string) error {
+	const op = "cache.Cache.Evict"
+
+	key, err := passport.Normalize(value)
+	if err != nil {
+		key = value
+	}
+
+	if err := c.backend.Delete(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ScheduleCleanup drops expired entries every interval until ctx is done.
+// Entries are only replaced when their passport is looked up again, and some
+// never are.
+func (c *Cache) ScheduleCleanup(ctx context.Context, interval time.Duration) {
+	const op = "cache.Cache.ScheduleCleanup"
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.backend.DeleteExpired(ctx); err != nil {
+			c.logger.Error(op, slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (c *Cache) Stats() dto.InfoCacheStats {
+	stats := dto.InfoCacheStats{
+		Backend:      c.backend.Name(),
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+	}
+
+	if total := stats.Hits + stats.NegativeHits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits+stats.NegativeHits) / float64(total)
+	}
+
+	return stats
+}
+
+// NewBackend builds the backend selected by cfg.InfoCacheBackend.
+func NewBackend(cfg *config.Config, db StorageInterface) (Backend, error) {
+	switch cfg.InfoCacheBackend {
+	case config.CacheMemory:
+		return NewMemory(cfg.InfoCacheSize), nil
+	case config.CachePostgres:
+		return NewPostgres(db), nil
+	default:
+		return nil, fmt.Errorf("unknown info cache backend %q", cfg.InfoCacheBackend)
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/transport/api"
+)
+
+type fakeAPI struct {
+	info  dto.UserInfoResponse
+	err   error
+	calls int
+}
+
+func (f *fakeAPI) Info(ctx context.Context, passport string) (dto.UserInfoResponse, error) {
+	f.calls++
+
+	return f.info, f.err
+}
+
+func TestCacheInfo(t *testing.T) {
+	info := dto.UserInfoResponse{Name: "Иван", Surname: "Иванов", Patronymic: "Иванович", Adress: "Москва"}
+
+	tests := []struct {
+		name        string
+		err         error
+		negativeTTL time.Duration
+		passports   []string
+		wantErr     error
+		wantCalls   int
+		wantStats   dto.InfoCacheStats
+	}{
+		{
+			name:      "found",
+			passports: []string{"1234 567890", "1234 567890"},
+			wantCalls: 1,
+			wantStats: dto.InfoCacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name:      "same passport written differently",
+			passports: []string{"1234 567890", "1234567890"},
+			wantCalls: 1,
+			wantStats: dto.InfoCacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name:        "not found",
+			err:         api.ErrNotFound,
+			negativeTTL: time.Minute,
+			passports:   []string{"1234 567890", "1234 567890"},
+			wantErr:     api.ErrNotFound,
+			wantCalls:   1,
+			wantStats:   dto.InfoCacheStats{NegativeHits: 1, Misses: 1},
+		},
+		{
+			name:      "not found without negative ttl",
+			err:       api.ErrNotFound,
+			passports: []string{"1234 567890", "1234 567890"},
+			wantErr:   api.ErrNotFound,
+			wantCalls: 2,
+			wantStats: dto.InfoCacheStats{Misses: 2},
+		},
+		{
+			name:        "unavailable",
+			err:         api.ErrUnavailable,
+			negativeTTL: time.Minute,
+			passports:   []string{"1234 567890", "1234 567890"},
+			wantErr:     api.ErrUnavailable,
+			wantCalls:   2,
+			wantStats:   dto.InfoCacheStats{Misses: 2},
+		},
+		{
+			name:      "invalid passport",
+			passports: []string{"1234", "1234"},
+			wantCalls: 2,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeAPI{info: info, err: tt.err}
+
+			c := New(logger, next, NewMemory(10), time.Minute, tt.negativeTTL)
+
+			for _, passport := range tt.passports {
+				got, err := c.Info(ctx, passport)
+
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("Info(%q) error = %v, want %v", passport, err, tt.wantErr)
+					}
+
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("Info(%q) error = %v", passport, err)
+				}
+
+				if got != info {
+					t.Errorf("Info(%q) = %+v, want %+v", passport, got, info)
+				}
+			}
+
+			if next.calls != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", next.calls, tt.wantCalls)
+			}
+
+			stats := c.Stats()
+			stats.Backend, stats.HitRatio = "", 0
+
+			if stats != tt.wantStats {
+				t.Errorf("Stats = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCacheEvict(t *testing.T) {
+	tests := []struct {
+		name  string
+		evict string
+	}{
+		{name: "same spelling", evict: "1234 567890"},
+		{name: "other spelling", evict: "1234567890"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeAPI{info: dto.UserInfoResponse{Name: "Иван"}}
+			c := New(logger, next, NewMemory(10), time.Minute, time.Minute)
+
+			if _, err := c.Info(ctx, "1234 567890"); err != nil {
+				t.Fatalf("Info error = %v", err)
+			}
+
+			if err := c.Evict(ctx, tt.evict); err != nil {
+				t.Fatalf("Evict error = %v", err)
+			}
+
+			if _, err := c.Info(ctx, "1234 567890"); err != nil {
+				t.Fatalf("Info error = %v", err)
+			}
+
+			if next.calls != 2 {
+				t.Errorf("upstream called %d times, want 2", next.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// Memory is a least recently used cache holding at most size entries.
+type Memory struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func NewMemory(size int) *Memory {
+	return &Memory{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (m *Memory) Name() string {
+	return config.CacheMemory
+}
+
+func (m *Memory) Get(_ context.Context, passport string) (entity.InfoCacheEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[passport]
+	if !ok {
+		return entity.InfoCacheEntry{}, false, nil
+	}
+
+	entry := element.Value.(entity.InfoCacheEntry)
+
+	if !time.Now().Before(entry.ExpiresAt) {
+		m.order.Remove(element)
+		delete(m.entries, passport)
+
+		return entity.InfoCacheEntry{}, false, nil
+	}
+
+	m.order.MoveToFront(element)
+
+	return entry, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, entry entity.InfoCacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[entry.Passport]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+
+		return nil
+	}
+
+	m.entries[entry.Passport] = m.order.PushFront(entry)
+
+	for m.size > 0 && m.order.Len() > m.size {
+		oldest := m.order.Back()
+
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(entity.InfoCacheEntry).Passport)
+	}
+
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, passport string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[passport]; ok {
+		m.order.Remove(element)
+		delete(m.entries, passport)
+	}
+
+	return nil
+}
+
+func (m *Memory) DeleteExpired(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	for passport, element := range m.entries {
+		if !now.Before(element.Value.(entity.InfoCacheEntry).ExpiresAt) {
+			m.order.Remove(element)
+			delete(m.entries, passport)
+		}
+	}
+
+	return nil
+}
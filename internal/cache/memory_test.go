@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+func TestMemory(t *testing.T) {
+	fresh := time.Now().Add(time.Hour)
+	stale := time.Now().Add(-time.Second)
+
+	entry := func(passport string, expiresAt time.Time) entity.InfoCacheEntry {
+		return entity.InfoCacheEntry{Passport: passport, Name: "name " + passport, ExpiresAt: expiresAt}
+	}
+
+	tests := []struct {
+		name    string
+		size    int
+		set     []entity.InfoCacheEntry
+		get     []string
+		delete  []string
+		expired bool
+		want    []string
+	}{
+		{
+			name: "under capacity",
+			size: 3,
+			set:  []entity.InfoCacheEntry{entry("a", fresh), entry("b", fresh)},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "oldest evicted",
+			size: 2,
+			set:  []entity.InfoCacheEntry{entry("a", fresh), entry("b", fresh), entry("c", fresh)},
+			want: []string{"b", "c"},
+		},
+		{
+			name: "read keeps entry",
+			size: 2,
+			set:  []entity.InfoCacheEntry{entry("a", fresh), entry("b", fresh), entry("c", fresh)},
+			get:  []string{"a"},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "replacing keeps entry",
+			size: 2,
+			set:  []entity.InfoCacheEntry{entry("a", fresh), entry("b", fresh), entry("a", fresh), entry("c", fresh)},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "no limit",
+			size: 0,
+			set:  []entity.InfoCacheEntry{entry("a", fresh), entry("b", fresh), entry("c", fresh)},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "expired is a miss",
+			size: 3,
+			set:  []entity.InfoCacheEntry{entry("a", stale), entry("b", fresh)},
+			want: []string{"b"},
+		},
+		{
+			name:   "deleted",
+			size:   3,
+			set:    []entity.InfoCacheEntry{entry("a", fresh), entry("b", fresh)},
+			delete: []string{"a", "unknown"},
+			want:   []string{"b"},
+		},
+		{
+			name:    "expired deleted",
+			size:    3,
+			set:     []entity.InfoCacheEntry{entry("a", stale), entry("b", fresh), entry("c", stale)},
+			expired: true,
+			want:    []string{"b"},
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(tt.size)
+
+			for i, e := range tt.set {
+				// Reads happen right before the last entry is set.
+				if i == len(tt.set)-1 {
+					for _, passport := range tt.get {
+						if _, _, err := m.Get(ctx, passport); err != nil {
+							t.Fatalf("Get error = %v", err)
+						}
+					}
+				}
+
+				if err := m.Set(ctx, e); err != nil {
+					t.Fatalf("Set error = %v", err)
+				}
+			}
+
+			for _, passport := range tt.delete {
+				if err := m.Delete(ctx, passport); err != nil {
+					t.Fatalf("Delete error = %v", err)
+				}
+			}
+
+			if tt.expired {
+				if err := m.DeleteExpired(ctx); err != nil {
+					t.Fatalf("DeleteExpired error = %v", err)
+				}
+
+				if m.order.Len() != len(tt.want) {
+					t.Errorf("%d entries left, want %d", m.order.Len(), len(tt.want))
+				}
+			}
+
+			for _, passport := range []string{"a", "b", "c"} {
+				got, ok, err := m.Get(ctx, passport)
+				if err != nil {
+					t.Fatalf("Get error = %v", err)
+				}
+
+				if want := slices.Contains(tt.want, passport); ok != want {
+					t.Errorf("Get(%q) found = %v, want %v", passport, ok, want)
+				}
+
+				if ok && got.Name != "name "+passport {
+					t.Errorf("Get(%q) = %+v", passport, got)
+				}
+			}
+		})
+	}
+}
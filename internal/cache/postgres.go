@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+type StorageInterface interface {
+	GetInfoCache(context.Context, string) (entity.InfoCacheEntry, error)
+	SetInfoCache(context.Context, entity.InfoCacheEntry) error
+	DeleteInfoCache(context.Context, string) error
+	DeleteExpiredInfoCache(context.Context) error
+}
+
+// Postgres keeps entries in the info_cache table, so they are shared between
+// instances and survive restarts.
+type Postgres struct {
+	db StorageInterface
+}
+
+func NewPostgres(db StorageInterface) *Postgres {
+	return &Postgres{
+		db: db,
+	}
+}
+
+func (p *Postgres) Name() string {
+	return config.CachePostgres
+}
+
+func (p *Postgres) Get(ctx context.Context, passport string) (entity.InfoCacheEntry, bool, error) {
+	entry, err := p.db.GetInfoCache(ctx, passport)
+	if errors.Is(err, storage.ErrNotFound) {
+		return entity.InfoCacheEntry{}, false, nil
+	}
+
+	if err != nil {
+		return entity.InfoCacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+func (p *Postgres) Set(ctx context.Context, entry entity.InfoCacheEntry) error {
+	return p.db.SetInfoCache(ctx, entry)
+}
+
+func (p *Postgres) Delete(ctx context.Context, passport string) error {
+	return p.db.DeleteInfoCache(ctx, passport)
+}
+
+func (p *Postgres) DeleteExpired(ctx context.Context) error {
+	return p.db.DeleteExpiredInfoCache(ctx)
+}
@@ -1,20 +1,84 @@
 package config
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
 )
 
 type Config struct {
 	Address    string `env:"ADDRESS" env-required:"true"`
+	PublicURL  string `env:"PUBLIC_URL"`
 	DBHost     string `env:"DB_HOST" env-required:"true"`
 	DBPort     int    `env:"DB_PORT" env-required:"true" env-default:"5432"`
 	DBName     string `env:"DB_NAME" env-required:"true"`
 	DBUser     string `env:"DB_USER" env-required:"true"`
 	DBPassword string `env:"DB_PWD" env-required:"true"`
 	InfoAPIURL string `env:"API" env-required:"true"`
+
+	DBMaxConns          int32         `env:"DB_MAX_CONNS" env-default:"10"`
+	DBMinConns          int32         `env:"DB_MIN_CONNS" env-default:"2"`
+	DBMaxConnLifetime   time.Duration `env:"DB_MAX_CONN_LIFETIME" env-default:"1h"`
+	DBMaxConnIdleTime   time.Duration `env:"DB_MAX_CONN_IDLE_TIME" env-default:"30m"`
+	DBHealthCheckPeriod time.Duration `env:"DB_HEALTH_CHECK_PERIOD" env-default:"1m"`
+	DBConnectTimeout    time.Duration `env:"DB_CONNECT_TIMEOUT" env-default:"5s"`
+	DBTxIsolation       string        `env:"DB_TX_ISOLATION" env-default:"read committed"`
+	DBTxRetries         int           `env:"DB_TX_RETRIES" env-default:"3"`
+
+	InfoAPITimeout          time.Duration `env:"API_TIMEOUT" env-default:"5s"`
+	InfoAPIRetries          int           `env:"API_RETRIES" env-default:"3"`
+	InfoAPIBackoff          time.Duration `env:"API_BACKOFF" env-default:"200ms"`
+	InfoAPIMaxBackoff       time.Duration `env:"API_MAX_BACKOFF" env-default:"2s"`
+	InfoAPIBreakerThreshold int           `env:"API_BREAKER_THRESHOLD" env-default:"5"`
+	InfoAPIBreakerCooldown  time.Duration `env:"API_BREAKER_COOLDOWN" env-default:"30s"`
+
+	InfoCacheBackend         string        `env:"INFO_CACHE_BACKEND" env-default:"memory"`
+	InfoCacheSize            int           `env:"INFO_CACHE_SIZE" env-default:"10000"`
+	InfoCacheTTL             time.Duration `env:"INFO_CACHE_TTL" env-default:"24h"`
+	InfoCacheNegativeTTL     time.Duration `env:"INFO_CACHE_NEGATIVE_TTL" env-default:"1h"`
+	InfoCacheCleanupInterval time.Duration `env:"INFO_CACHE_CLEANUP_INTERVAL" env-default:"1h"`
+
+	EnrichmentWorkers      int           `env:"ENRICHMENT_WORKERS" env-default:"2"`
+	EnrichmentPollInterval time.Duration `env:"ENRICHMENT_POLL_INTERVAL" env-default:"1s"`
+	EnrichmentMaxAttempts  int           `env:"ENRICHMENT_MAX_ATTEMPTS" env-default:"5"`
+	EnrichmentRetryBackoff time.Duration `env:"ENRICHMENT_RETRY_BACKOFF" env-default:"30s"`
+	EnrichmentLease        time.Duration `env:"ENRICHMENT_LEASE" env-default:"5m"`
+
+	SyncInterval  time.Duration `env:"SYNC_INTERVAL" env-default:"24h"`
+	SyncBatchSize int           `env:"SYNC_BATCH_SIZE" env-default:"100"`
+	SyncTimeout   time.Duration `env:"SYNC_TIMEOUT" env-default:"1h"`
+
+	ImportConcurrency int   `env:"IMPORT_CONCURRENCY" env-default:"8"`
+	ImportBatchSize   int   `env:"IMPORT_BATCH_SIZE" env-default:"500"`
+	ImportMaxRows     int   `env:"IMPORT_MAX_ROWS" env-default:"1000"`
+	ImportMaxBytes    int64 `env:"IMPORT_MAX_BYTES" env-default:"1048576"`
+
+	CursorSecret string `env:"CURSOR_SECRET"`
+
+	RunningTaskPolicy string `env:"RUNNING_TASK_POLICY" env-default:"conflict"`
+
+	AdminAPIKey      string `env:"ADMIN_API_KEY"`
+	JWTSecret        string `env:"JWT_SECRET"`
+	JWTPublicKeyFile string `env:"JWT_PUBLIC_KEY_FILE"`
+	JWTIssuer        string `env:"JWT_ISSUER"`
+	JWTAudience      string `env:"JWT_AUDIENCE"`
 }
 
+const (
+	PolicyConflict = "conflict"
+	PolicyAutoStop = "auto_stop"
+)
+
+const (
+	CacheMemory   = "memory"
+	CachePostgres = "postgres"
+)
+
 func LoadConfig() (*Config, error) {
 	err := godotenv.Load("./.env")
 	if err != nil {
@@ -28,5 +92,56 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// Links handed out to clients point at PublicURL, the server itself
+	// unless it sits behind a proxy.
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = "http://" + cfg.Address
+	}
+
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+
+	switch cfg.RunningTaskPolicy {
+	case PolicyConflict, PolicyAutoStop:
+	default:
+		return nil, fmt.Errorf("unknown running task policy %q, must be %s or %s", cfg.RunningTaskPolicy, PolicyConflict, PolicyAutoStop)
+	}
+
+	switch cfg.DBTxIsolation {
+	case entity.IsolationReadCommitted, entity.IsolationRepeatableRead, entity.IsolationSerializable:
+	default:
+		return nil, fmt.Errorf("unknown transaction isolation %q, must be %s, %s or %s", cfg.DBTxIsolation, entity.IsolationReadCommitted, entity.IsolationRepeatableRead, entity.IsolationSerializable)
+	}
+
+	err = positive(map[string]int64{
+		"IMPORT_BATCH_SIZE":  int64(cfg.ImportBatchSize),
+		"IMPORT_CONCURRENCY": int64(cfg.ImportConcurrency),
+		"IMPORT_MAX_ROWS":    int64(cfg.ImportMaxRows),
+		"IMPORT_MAX_BYTES":   cfg.ImportMaxBytes,
+		"SYNC_BATCH_SIZE":    int64(cfg.SyncBatchSize),
+		"ENRICHMENT_WORKERS": int64(cfg.EnrichmentWorkers),
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// positive fails for the first of settings, by name, that is not greater
+// than zero. Batch sizes and limits like these never make sense otherwise.
+func positive(settings map[string]int64) error {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		if settings[name] <= 0 {
+			return fmt.Errorf("%s must be greater than zero, got %d", name, settings[name])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+var ErrInvalid = errors.New("invalid cursor")
+
+// Cursor points at a page of users next to the user UserID, whose sort value
+// is Key. It carries the filter, order and page size it was issued for, so a
+// client cannot page through one result set with the filter of another.
+type Cursor struct {
+	Filter   entity.FilterOptions `json:"f"`
+	Sort     string               `json:"s"`
+	Order    string               `json:"o"`
+	Key      any                  `json:"k,omitempty"`
+	UserID   int                  `json:"id"`
+	Backward bool                 `json:"b,omitempty"`
+	Limit    int                  `json:"l"`
+}
+
+// Signer encodes cursors into opaque tokens and checks the ones it gets
+// back. Tokens are signed, not encrypted: their content is readable but
+// cannot be changed.
+type Signer struct {
+	key []byte
+}
+
+// New returns a Signer using secret as its key. Without a secret a random key
+// is used, and tokens stop working when the process restarts.
+func New(secret string) *Signer {
+	key := []byte(secret)
+
+	if len(key) == 0 {
+		key = make([]byte, sha256.Size)
+
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+
+	return &Signer{key: key}
+}
+
+func (s *Signer) Encode(c Cursor) string {
+	payload, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+func (s *Signer) Decode(token string) (Cursor, error) {
+	encoded, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Cursor{}, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalid
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.sign(payload)) {
+		return Cursor{}, ErrInvalid
+	}
+
+	var c Cursor
+
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Cursor{}, ErrInvalid
+	}
+
+	return c, nil
+}
+
+func (s *Signer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
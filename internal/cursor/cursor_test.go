@@ -0,0 +1,98 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{
+			name:   "first page",
+			cursor: Cursor{Sort: "user_id", Order: "asc", UserID: 10, Limit: 10},
+		},
+		{
+			name: "string key with filter",
+			cursor: Cursor{
+				Filter: entity.FilterOptions{Surname: "Иванов", SurnameMatch: "prefix", IncludeDeleted: true},
+				Sort:   "surname",
+				Order:  "desc",
+				Key:    "Иванов",
+				UserID: 3,
+				Limit:  50,
+			},
+		},
+		{
+			name: "number key backward",
+			cursor: Cursor{
+				Filter:   entity.FilterOptions{Query: "ivan"},
+				Sort:     "relevance",
+				Order:    "desc",
+				Key:      0.75,
+				UserID:   8,
+				Backward: true,
+				Limit:    20,
+			},
+		},
+	}
+
+	s := New("secret")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Decode(s.Encode(tt.cursor))
+			if err != nil {
+				t.Fatalf("Decode error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.cursor) {
+				t.Errorf("Decode = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	s := New("secret")
+	token := s.Encode(Cursor{Sort: "user_id", Order: "asc", UserID: 10, Limit: 10})
+	payload, signature, _ := strings.Cut(token, ".")
+
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"user_id","o":"asc","id":11,"l":10}`))
+	notJSON := []byte("not json")
+
+	tests := []struct {
+		name   string
+		signer *Signer
+		token  string
+	}{
+		{name: "empty", signer: s, token: ""},
+		{name: "without signature", signer: s, token: payload},
+		{name: "payload not base64", signer: s, token: "!!!." + signature},
+		{name: "signature not base64", signer: s, token: payload + ".!!!"},
+		{name: "truncated signature", signer: s, token: payload + "." + signature[:len(signature)-2]},
+		{name: "changed payload", signer: s, token: tampered + "." + signature},
+		{name: "other secret", signer: New("other secret"), token: token},
+		{name: "random key", signer: New(""), token: token},
+		{
+			name:   "signed but not json",
+			signer: s,
+			token:  base64.RawURLEncoding.EncodeToString(notJSON) + "." + base64.RawURLEncoding.EncodeToString(s.sign(notJSON)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.signer.Decode(tt.token); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode error = %v, want %v", err, ErrInvalid)
+			}
+		})
+	}
+}
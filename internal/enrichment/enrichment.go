@@ -0,0 +1,148 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/audit"
+	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/service"
+	"github.com/njslxve/time-tracker-service/internal/transport/api"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+// Actor is recorded in the audit log for changes made by the workers.
+const Actor = "enrichment"
+
+type StorageInterface interface {
+	ClaimEnrichmentJob(context.Context, time.Duration) (entity.EnrichmentJob, error)
+	CompleteEnrichmentJob(context.Context, entity.EnrichmentJob, entity.User) error
+	RetryEnrichmentJob(context.Context, entity.EnrichmentJob, string, time.Time) error
+	FailEnrichmentJob(context.Context, entity.EnrichmentJob, string) error
+}
+
+// Workers fill in personal data of newly added users from the people info
+// service. Jobs live in Postgres, so any number of instances can run workers
+// side by side.
+type Workers struct {
+	logger *slog.Logger
+	cfg    *config.Config
+	db     StorageInterface
+	api    service.APIInterface
+}
+
+func New(logger *slog.Logger, cfg *config.Config, db StorageInterface, api service.APIInterface) *Workers {
+	return &Workers{
+		logger: logger,
+		cfg:    cfg,
+		db:     db,
+		api:    api,
+	}
+}
+
+// Run starts the configured number of workers and blocks until ctx is done
+// and all of them have returned.
+func (w *Workers) Run(ctx context.Context) {
+	ctx = audit.WithActor(ctx, audit.Actor{Subject: Actor})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < w.cfg.EnrichmentWorkers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			w.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// work processes jobs back to back while there are any and polls otherwise.
+func (w *Workers) work(ctx context.Context) {
+	const op = "enrichment.Workers.work"
+
+	ticker := time.NewTicker(w.cfg.EnrichmentPollInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := w.process(ctx)
+		if err != nil {
+			w.logger.Error(op, slog.String("error", err.Error()))
+		}
+
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Workers) process(ctx context.Context) (bool, error) {
+	const op = "enrichment.Workers.process"
+
+	job, err := w.db.ClaimEnrichmentJob(ctx, w.cfg.EnrichmentLease)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userInfo, err := w.api.Info(ctx, job.Passport)
+	if err == nil {
+		err = w.db.CompleteEnrichmentJob(ctx, job, entity.User{
+			UserID:     job.UserID,
+			Name:       userInfo.Name,
+			Surmame:    userInfo.Surname,
+			Patronymic: userInfo.Patronymic,
+			Adress:     userInfo.Adress,
+		})
+		if err != nil {
+			return true, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return true, nil
+	}
+
+	w.logger.Debug("enrichment failed",
+		slog.Int64("job", job.ID),
+		slog.Int("attempt", job.Attempts),
+		slog.String("error", err.Error()),
+	)
+
+	if permanent(err) || job.Attempts >= w.cfg.EnrichmentMaxAttempts {
+		err = w.db.FailEnrichmentJob(ctx, job, err.Error())
+	} else {
+		err = w.db.RetryEnrichmentJob(ctx, job, err.Error(), time.Now().Add(w.backoff(job.Attempts)))
+	}
+
+	if err != nil {
+		return true, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// backoff doubles the configured delay with every failed attempt.
+func (w *Workers) backoff(attempts int) time.Duration {
+	return w.cfg.EnrichmentRetryBackoff << (attempts - 1)
+}
+
+// permanent reports whether retrying cannot change the answer.
+func permanent(err error) bool {
+	return errors.Is(err, api.ErrNotFound) || errors.Is(err, api.ErrRejected)
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type UserInfoRequest struct {
 	PassportSerie  string
@@ -14,45 +17,313 @@ type UserInfoResponse struct {
 	Adress     string `json:"adress"`
 }
 
+type InfoCacheStats struct {
+	Backend      string  `json:"backend"`
+	Hits         int64   `json:"hits"`
+	NegativeHits int64   `json:"negative_hits"`
+	Misses       int64   `json:"misses"`
+	HitRatio     float64 `json:"hit_ratio"`
+}
+
 type AddUserRequest struct {
-	Passport string `json:"passportNumber"`
+	Passport string `json:"passportNumber" validate:"required,passport"`
 }
 
 type UpdateUserRequest struct {
-	Name       string `json:"name,omitempty"`
-	Surname    string `json:"surname,omitempty"`
-	Patronymic string `json:"patronymic,omitempty"`
-	Passport   string `json:"passport,omitempty"`
-	Adress     string `json:"adress,omitempty"`
+	Name       string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Surname    string `json:"surname,omitempty" validate:"omitempty,max=100"`
+	Patronymic string `json:"patronymic,omitempty" validate:"omitempty,max=100"`
+	Passport   string `json:"passport,omitempty" validate:"omitempty,passport"`
+	Adress     string `json:"adress,omitempty" validate:"omitempty,max=255"`
 }
 
 type TaskRequest struct {
-	UserID int    `json:"user_id"`
-	TaskID string `json:"task_id"`
+	UserID int    `json:"user_id" validate:"required,gt=0"`
+	TaskID string `json:"task_id" validate:"required,max=100"`
+}
+
+type ManualTaskRequest struct {
+	UserID    int       `json:"user_id" validate:"required,gt=0"`
+	TaskID    string    `json:"task_id" validate:"required,max=100"`
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+}
+
+type UpdateTaskRequest struct {
+	StartTime *time.Time `json:"start_time,omitempty" validate:"required_without=EndTime"`
+	EndTime   *time.Time `json:"end_time,omitempty" validate:"omitempty,gtfield=StartTime"`
 }
 
 type TaskResponse struct {
-	TaskID    string    `json:"task_id"`
-	UserID    int       `json:"user_id"`
+	SessionID   string            `json:"session_id,omitempty"`
+	TaskID      string            `json:"task_id"`
+	Title       string            `json:"title,omitempty"`
+	ProjectID   int               `json:"project_id,omitempty"`
+	ProjectName string            `json:"project,omitempty"`
+	UserID      int               `json:"user_id"`
+	StartTime   time.Time         `json:"start_time"`
+	EndTime     time.Time         `json:"end_time"`
+	Duration    string            `json:"duration"`
+	Segments    []SegmentResponse `json:"segments"`
+}
+
+type TaskTotalResponse struct {
+	TaskID      string    `json:"task_id"`
+	Title       string    `json:"title"`
+	ProjectName string    `json:"project,omitempty"`
+	UserID      int       `json:"user_id"`
+	Sessions    int       `json:"sessions"`
+	FirstStart  time.Time `json:"first_start"`
+	LastEnd     time.Time `json:"last_end"`
+	Duration    string    `json:"duration"`
+}
+
+type SegmentResponse struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 	Duration  string    `json:"duration"`
 }
 
-type Error struct {
-	Message string `json:"error"`
+type ReportGroup struct {
+	Key         string `json:"key"`
+	Title       string `json:"title,omitempty"`
+	ProjectName string `json:"project,omitempty"`
+	Minutes     int    `json:"minutes"`
+	Duration    string `json:"duration"`
 }
 
-type User struct {
+type UserReportResponse struct {
+	UserID   int           `json:"user_id"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	GroupBy  string        `json:"group_by"`
+	Minutes  int           `json:"minutes"`
+	Duration string        `json:"duration"`
+	Groups   []ReportGroup `json:"groups"`
+}
+
+type UserTotal struct {
 	UserID     int    `json:"user_id"`
 	Surname    string `json:"surname"`
 	Name       string `json:"name"`
 	Patronymic string `json:"patronymic"`
-	Passport   string `json:"passport"`
-	Adress     string `json:"adress"`
+	Minutes    int    `json:"minutes"`
+	Duration   string `json:"duration"`
+}
+
+type SummaryReportResponse struct {
+	From   time.Time   `json:"from"`
+	To     time.Time   `json:"to"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	Users  []UserTotal `json:"users"`
+}
+
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Actor       string          `json:"actor"`
+	ActorUserID int             `json:"actor_user_id,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Entity      string          `json:"entity"`
+	EntityID    string          `json:"entity_id"`
+	Operation   string          `json:"operation"`
+	Before      json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After       json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+}
+
+type AuditLogResponse struct {
+	From    time.Time    `json:"from"`
+	To      time.Time    `json:"to"`
+	Total   int          `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+type TimesheetRow struct {
+	SessionID   string
+	UserID      int
+	Surname     string
+	Name        string
+	Patronymic  string
+	TaskID      string
+	Title       string
+	ProjectName string
+	StartTime   time.Time
+	EndTime     time.Time
+	Minutes     int
+	Duration    string
+}
+
+type ProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Project struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role"`
+}
+
+type TeamRequest struct {
+	Name      string `json:"name"`
+	ManagerID int    `json:"manager_id,omitempty"`
+}
+
+type TeamMemberRequest struct {
+	UserID int `json:"user_id"`
+}
+
+type Team struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	ManagerID int       `json:"manager_id,omitempty"`
+	Members   []int     `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKeyRequest struct {
+	Name   string `json:"name"`
+	UserID int    `json:"user_id,omitempty"`
+	Admin  bool   `json:"admin,omitempty"`
+}
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	UserID     int        `json:"user_id,omitempty"`
+	Admin      bool       `json:"admin"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type AddTaskDefinitionRequest struct {
+	TaskID      string `json:"task_id"`
+	ProjectID   int    `json:"project_id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type UpdateTaskDefinitionRequest struct {
+	ProjectID   *int   `json:"project_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type TaskDefinition struct {
+	TaskID      string `json:"task_id"`
+	ProjectID   int    `json:"project_id,omitempty"`
+	ProjectName string `json:"project,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type Health struct {
+	Status string `json:"status"`
+}
+
+// Problem is an RFC 7807 problem details object. Code tells problems apart
+// for programs and Errors lists the fields a request failed validation on.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Code      string       `json:"code"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type RunningTaskProblem struct {
+	Problem
+	Task TaskResponse `json:"running_task"`
+}
+
+type User struct {
+	UserID     int            `json:"user_id"`
+	Surname    string         `json:"surname"`
+	Name       string         `json:"name"`
+	Patronymic string         `json:"patronymic"`
+	Passport   string         `json:"passport"`
+	Adress     string         `json:"adress"`
+	Status     string         `json:"status"`
+	Overridden []string       `json:"overridden_fields,omitempty"`
+	Enrichment *EnrichmentJob `json:"enrichment,omitempty"`
+	DeletedAt  *time.Time     `json:"deleted_at,omitempty"`
+}
+
+type SyncRun struct {
+	ID         int64      `json:"id"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Checked    int        `json:"users_checked"`
+	Updated    int        `json:"users_updated"`
+	Failed     int        `json:"users_failed"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+type EnrichmentJob struct {
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type AddUserResponse struct {
+	UserID int    `json:"user_id"`
+	Status string `json:"status"`
+}
+
+type ImportRow struct {
+	Row      int               `json:"row"`
+	Passport string            `json:"passport"`
+	Status   string            `json:"status"`
+	UserID   int               `json:"user_id,omitempty"`
+	Info     *UserInfoResponse `json:"info,omitempty"`
+	Code     string            `json:"code,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Fields   []FieldError      `json:"fields,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun   bool        `json:"dry_run"`
+	Total    int         `json:"total"`
+	Imported int         `json:"imported"`
+	Skipped  int         `json:"skipped"`
+	Failed   int         `json:"failed"`
+	Rows     []ImportRow `json:"rows"`
 }
 
 type GetUsersResponse struct {
 	Users []User `json:"users"`
 	Next  string `json:"next_page,omitempty"`
+	Prev  string `json:"prev_page,omitempty"`
 }
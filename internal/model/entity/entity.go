@@ -10,31 +10,318 @@ type User struct {
 	Surmame    string
 	Patronymic string
 	Adress     string
+	Status     string
+	Overridden []string
+	DeletedAt  *time.Time
+	// SortKey is the value the user was ordered by when listed with a sort
+	// other than user_id.
+	SortKey any
 }
 
+// Fields filled in from the people info service, named after their columns.
+// Once set by hand they are listed in User.Overridden and left alone by sync.
+const (
+	FieldName       = "first_name"
+	FieldSurname    = "last_name"
+	FieldPatronymic = "patronymic"
+	FieldAdress     = "adress"
+)
+
+const (
+	UserPendingEnrichment = "pending_enrichment"
+	UserEnriched          = "enriched"
+	UserEnrichmentFailed  = "enrichment_failed"
+)
+
+type EnrichmentJob struct {
+	ID        int64
+	UserID    int
+	Passport  string
+	Status    string
+	Attempts  int
+	LastError string
+	RunAt     time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
 type Task struct {
+	ID          string
+	TaskID      string
+	UserID      int
+	StartTime   time.Time
+	EndTime     time.Time
+	Duration    int
+	Segments    []Segment
+	Title       string
+	ProjectID   int
+	ProjectName string
+}
+
+type Project struct {
+	ID          int
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+const (
+	RoleEmployee = "employee"
+	RoleManager  = "manager"
+	RoleAdmin    = "admin"
+)
+
+type Team struct {
+	ID        int
+	Name      string
+	ManagerID int
+	Members   []int
+	CreatedAt time.Time
+}
+
+// SyncRun is one pass of comparing enriched users with the people info
+// service, with the number of users it checked, updated and failed to look up.
+type SyncRun struct {
+	ID         int64
+	Trigger    string
+	Status     string
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	Checked    int
+	Updated    int
+	Failed     int
+	LastError  string
+}
+
+const (
+	SyncScheduled = "schedule"
+	SyncManual    = "manual"
+
+	SyncRunning = "running"
+	SyncDone    = "done"
+	SyncFailed  = "failed"
+)
+
+// Results of a single row of a user import.
+const (
+	ImportCreated   = "created"
+	ImportValid     = "valid"
+	ImportInvalid   = "invalid"
+	ImportDuplicate = "duplicate"
+	ImportExists    = "exists"
+	ImportFailed    = "failed"
+)
+
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// InfoCacheEntry is a stored answer of the people info service. NotFound
+// entries remember passports the service does not know.
+type InfoCacheEntry struct {
+	Passport   string
+	Name       string
+	Surname    string
+	Patronymic string
+	Adress     string
+	NotFound   bool
+	ExpiresAt  time.Time
+}
+
+type AuditEntry struct {
+	ID          int64
+	OccurredAt  time.Time
+	Actor       string
+	ActorUserID int
+	RequestID   string
+	Entity      string
+	EntityID    string
+	Operation   string
+	Before      []byte
+	After       []byte
+}
+
+type AuditOptions struct {
+	Entity   string
+	EntityID string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+type APIKey struct {
+	ID         int
+	Name       string
+	KeyHash    string
+	UserID     int
+	Admin      bool
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+type TaskDefinition struct {
+	TaskID      string
+	ProjectID   int
+	ProjectName string
+	Title       string
+	Description string
+}
+
+type TaskTotal struct {
+	TaskID      string
+	Title       string
+	ProjectName string
+	UserID      int
+	Sessions    int
+	FirstStart  time.Time
+	LastEnd     time.Time
+	Duration    int
+}
+
+type Segment struct {
 	ID        string
-	TaskID    string
-	UserID    int
+	TaskUUID  string
 	StartTime time.Time
 	EndTime   time.Time
-	Duration  int
 }
 
+const (
+	GroupByTask    = "task"
+	GroupByDay     = "day"
+	GroupByWeek    = "week"
+	GroupByMonth   = "month"
+	GroupByProject = "project"
+)
+
+type ReportOptions struct {
+	UserID  int
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+type ReportRow struct {
+	Key         string
+	Title       string
+	ProjectName string
+	Duration    int
+}
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+type SummaryOptions struct {
+	From   time.Time
+	To     time.Time
+	Filter FilterOptions
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+type UserTotal struct {
+	UserID     int
+	Name       string
+	Surname    string
+	Patronymic string
+	Duration   int
+}
+
+type TimesheetOptions struct {
+	UserID      int
+	From        time.Time
+	To          time.Time
+	Filter      FilterOptions
+	IncludeOpen bool
+}
+
+type TimesheetRow struct {
+	SessionID   string
+	UserID      int
+	Name        string
+	Surname     string
+	Patronymic  string
+	TaskID      string
+	Title       string
+	ProjectName string
+	StartTime   time.Time
+	EndTime     time.Time
+	Duration    int
+}
+
+// How a name or address filter matches. Exact matching ignores case, fuzzy
+// matching uses trigram similarity.
+const (
+	MatchExact    = "exact"
+	MatchPrefix   = "prefix"
+	MatchContains = "contains"
+	MatchFuzzy    = "fuzzy"
+)
+
 type FilterOptions struct {
-	Name       string `json:"name,omitempty"`
-	Surname    string `json:"surname,omitempty"`
-	Patronymic string `json:"patronymic,omitempty"`
-	Adress     string `json:"adress,omitempty"`
+	Name            string `json:"name,omitempty"`
+	NameMatch       string `json:"name_match,omitempty"`
+	Surname         string `json:"surname,omitempty"`
+	SurnameMatch    string `json:"surname_match,omitempty"`
+	Patronymic      string `json:"patronymic,omitempty"`
+	PatronymicMatch string `json:"patronymic_match,omitempty"`
+	Adress          string `json:"adress,omitempty"`
+	AdressMatch     string `json:"adress_match,omitempty"`
+	Query           string `json:"q,omitempty"`
+	PassportSeries  string `json:"passport_series,omitempty"`
+	ManagerID       int    `json:"manager_id,omitempty"`
+	IncludeDeleted  bool   `json:"include_deleted,omitempty"`
 }
 
+// Orders of a user list. SortRelevance needs FilterOptions.Query.
+const (
+	SortUserID    = "user_id"
+	SortName      = "name"
+	SortSurname   = "surname"
+	SortRelevance = "relevance"
+)
+
 type PaginationOptions struct {
 	Limit int
+	Sort  string
+	Order string
 	Next  string
+	Prev  string
 }
 
-type TokenData struct {
-	Params  []byte
-	Old     int
-	IsAlive bool
+// Page selects up to Limit users in Sort and Order after the user AfterID,
+// or before BeforeID when it is set. Key is the sort value of that user.
+type Page struct {
+	Sort     string
+	Order    string
+	Key      any
+	AfterID  int
+	BeforeID int
+	Limit    int
+}
+
+const (
+	IsolationReadCommitted  = "read committed"
+	IsolationRepeatableRead = "repeatable read"
+	IsolationSerializable   = "serializable"
+)
+
+// TxOptions configure a unit of work: its isolation level and how many times
+// it is run again after a serialization failure.
+type TxOptions struct {
+	Isolation string
+	Retries   int
 }
@@ -0,0 +1,125 @@
+package passport
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	seriesLength = 4
+	numberLength = 6
+)
+
+// Parts of a passport named in validation errors.
+const (
+	FieldPassport = "passport"
+	FieldSeries   = "series"
+	FieldNumber   = "number"
+)
+
+// Passport is a parsed passport: a series of 4 digits and a number of 6.
+type Passport struct {
+	Series string
+	Number string
+}
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error lists every part of a passport that failed to parse.
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+
+	return "invalid passport: " + strings.Join(messages, ", ")
+}
+
+// Parse reads a passport written as series and number separated by any run of
+// whitespace and dashes, or as 10 digits in a row, possibly broken up
+// further. Anything else is reported as an *Error.
+func Parse(s string) (Passport, error) {
+	groups := strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-'
+	})
+
+	if len(groups) == 2 {
+		var e Error
+
+		if msg := checkPart(groups[0], seriesLength); msg != "" {
+			e.Fields = append(e.Fields, FieldError{Field: FieldSeries, Message: msg})
+		}
+
+		if msg := checkPart(groups[1], numberLength); msg != "" {
+			e.Fields = append(e.Fields, FieldError{Field: FieldNumber, Message: msg})
+		}
+
+		if len(e.Fields) > 0 {
+			return Passport{}, &e
+		}
+	}
+
+	joined := strings.Join(groups, "")
+
+	if len(joined) != seriesLength+numberLength || !digits(joined) {
+		return Passport{}, &Error{Fields: []FieldError{{
+			Field:   FieldPassport,
+			Message: "must be a series of 4 digits followed by a number of 6 digits",
+		}}}
+	}
+
+	return Passport{
+		Series: joined[:seriesLength],
+		Number: joined[seriesLength:],
+	}, nil
+}
+
+// Normalize returns the canonical form of a passport or the parse error.
+func Normalize(s string) (string, error) {
+	p, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	return p.String(), nil
+}
+
+// ValidSeries reports whether s is a passport series on its own.
+func ValidSeries(s string) bool {
+	return len(s) == seriesLength && digits(s)
+}
+
+// String returns the canonical form used for storage: series and number
+// separated by a single space.
+func (p Passport) String() string {
+	return p.Series + " " + p.Number
+}
+
+func checkPart(part string, length int) string {
+	switch {
+	case !digits(part):
+		return "must contain only digits"
+	case len(part) != length:
+		return "must be " + strconv.Itoa(length) + " digits long"
+	default:
+		return ""
+	}
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
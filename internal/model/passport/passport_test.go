@@ -0,0 +1,144 @@
+package passport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   Passport
+		fields []FieldError
+	}{
+		{
+			name:  "canonical",
+			input: "1234 567890",
+			want:  Passport{Series: "1234", Number: "567890"},
+		},
+		{
+			name:  "digits in a row",
+			input: "1234567890",
+			want:  Passport{Series: "1234", Number: "567890"},
+		},
+		{
+			name:  "dash and extra whitespace",
+			input: "  1234 -\t567890 ",
+			want:  Passport{Series: "1234", Number: "567890"},
+		},
+		{
+			name:  "broken up further",
+			input: "12 34 567 890",
+			want:  Passport{Series: "1234", Number: "567890"},
+		},
+		{
+			name:   "short series",
+			input:  "123 567890",
+			fields: []FieldError{{Field: FieldSeries, Message: "must be 4 digits long"}},
+		},
+		{
+			name:   "letters in number",
+			input:  "1234 56789a",
+			fields: []FieldError{{Field: FieldNumber, Message: "must contain only digits"}},
+		},
+		{
+			name:  "both parts invalid",
+			input: "12a4 5678",
+			fields: []FieldError{
+				{Field: FieldSeries, Message: "must contain only digits"},
+				{Field: FieldNumber, Message: "must be 6 digits long"},
+			},
+		},
+		{
+			name:   "too few digits",
+			input:  "123456789",
+			fields: []FieldError{{Field: FieldPassport, Message: "must be a series of 4 digits followed by a number of 6 digits"}},
+		},
+		{
+			name:   "empty",
+			input:  "",
+			fields: []FieldError{{Field: FieldPassport, Message: "must be a series of 4 digits followed by a number of 6 digits"}},
+		},
+		{
+			name:   "non ascii digits",
+			input:  "١٢٣٤٥٦٧٨٩٠",
+			fields: []FieldError{{Field: FieldPassport, Message: "must be a series of 4 digits followed by a number of 6 digits"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", tt.input, err)
+				}
+
+				if got != tt.want {
+					t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+				}
+
+				return
+			}
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.input, err)
+			}
+
+			if !reflect.DeepEqual(e.Fields, tt.fields) {
+				t.Errorf("Parse(%q) fields = %+v, want %+v", tt.input, e.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "1234 567890", want: "1234 567890"},
+		{input: "1234567890", want: "1234 567890"},
+		{input: "1234-567890", want: "1234 567890"},
+		{input: "1234 56789", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Normalize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidSeries(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "1234", want: true},
+		{input: "123", want: false},
+		{input: "12345", want: false},
+		{input: "12a4", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ValidSeries(tt.input); got != tt.want {
+				t.Errorf("ValidSeries(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
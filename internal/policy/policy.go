@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/njslxve/time-tracker-service/internal/auth"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+type StorageInterface interface {
+	UserRole(context.Context, int) (string, error)
+	ManagesUser(context.Context, int, int) (bool, error)
+	GetSession(context.Context, string) (entity.Task, error)
+}
+
+// Policy decides what an authenticated principal may do. Handlers consult it
+// before calling the service, so the service itself stays unaware of callers.
+type Policy struct {
+	logger *slog.Logger
+	db     StorageInterface
+}
+
+func New(logger *slog.Logger, db StorageInterface) *Policy {
+	return &Policy{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Resolve fills in the principal's role. Admin credentials are admins
+// regardless of any stored role, users without a stored role are employees
+// and credentials not bound to a user get no role at all. Credentials of
+// archived users are rejected.
+func (p *Policy) Resolve(ctx context.Context, principal auth.Principal) (auth.Principal, error) {
+	const op = "policy.Policy.Resolve"
+
+	switch {
+	case principal.Admin:
+		principal.Role = entity.RoleAdmin
+	case principal.UserID != 0:
+		role, err := p.db.UserRole(ctx, principal.UserID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return auth.Principal{}, fmt.Errorf("%s: %w", op, auth.ErrUnauthenticated)
+		}
+
+		if err != nil {
+			return auth.Principal{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if role == "" {
+			role = entity.RoleEmployee
+		}
+
+		principal.Role = role
+	}
+
+	return principal, nil
+}
+
+func (p *Policy) Admin(principal auth.Principal) error {
+	if principal.Role != entity.RoleAdmin {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (p *Policy) Manager(principal auth.Principal) error {
+	if principal.Role != entity.RoleAdmin && principal.Role != entity.RoleManager {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// TrackTime allows changing the time records of userID: everyone may track
+// their own time, only admins may do it on behalf of others.
+func (p *Policy) TrackTime(principal auth.Principal, userID int) error {
+	if principal.Role == entity.RoleAdmin {
+		return nil
+	}
+
+	if principal.Role == "" || principal.UserID != userID {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (p *Policy) EditSession(ctx context.Context, principal auth.Principal, sessionID string) error {
+	const op = "policy.Policy.EditSession"
+
+	if principal.Role == entity.RoleAdmin {
+		return nil
+	}
+
+	session, err := p.db.GetSession(ctx, sessionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrForbidden
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p.TrackTime(principal, session.UserID)
+}
+
+// ReadUser allows reading the time records and reports of userID: the user
+// themselves, a manager of one of their teams and admins.
+func (p *Policy) ReadUser(ctx context.Context, principal auth.Principal, userID int) error {
+	const op = "policy.Policy.ReadUser"
+
+	if principal.Role == entity.RoleAdmin {
+		return nil
+	}
+
+	if principal.Role != "" && principal.UserID == userID {
+		return nil
+	}
+
+	if principal.Role != entity.RoleManager {
+		return ErrForbidden
+	}
+
+	manages, err := p.db.ManagesUser(ctx, principal.UserID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !manages {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// ReadTeam returns the manager whose teams bound what the principal may list:
+// zero for admins, who see everyone, and the principal's own user for
+// managers.
+func (p *Policy) ReadTeam(principal auth.Principal) (int, error) {
+	switch principal.Role {
+	case entity.RoleAdmin:
+		return 0, nil
+	case entity.RoleManager:
+		return principal.UserID, nil
+	default:
+		return 0, ErrForbidden
+	}
+}
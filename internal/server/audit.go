@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// @Summary get audit log
+// @Tags audit
+// @Description get recorded mutations, newest first
+// @Accept json
+// @Produce json
+// @Param entity query string false "entity, e.g. users, tasks or projects"
+// @Param entity_id query string false "entity id"
+// @Param from query string false "period start, RFC3339 (default: 30 days before to)"
+// @Param to query string false "period end, RFC3339 (default: now)"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Success 200 {object} dto.AuditLogResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /audit [get]
+func (s *Server) getAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getAuditLogHandler"
+
+	from, to, err := reportPeriod(r)
+	if err != nil {
+		s.badRequest(w, r, op, err, err.Error())
+
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	log, err := s.service.GetAuditLog(r.Context(), entity.AuditOptions{
+		Entity:   r.URL.Query().Get("entity"),
+		EntityID: r.URL.Query().Get("entity_id"),
+		From:     from,
+		To:       to,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(log)
+	}
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/njslxve/time-tracker-service/internal/audit"
+	"github.com/njslxve/time-tracker-service/internal/auth"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/policy"
+)
+
+const (
+	UnauthorizedError = "Authentication required, provide an API key or a bearer token"
+	ForbiddenError    = "You are not allowed to perform this action"
+)
+
+// authenticate accepts either an API key in X-API-Key or a bearer token in
+// Authorization. Bearer tokens shaped like a JWT are verified as such, any
+// other bearer token is treated as an API key.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const op = "server.Server.authenticate"
+
+		var (
+			principal auth.Principal
+			err       error
+		)
+
+		key := r.Header.Get("X-API-Key")
+		token, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		switch {
+		case key != "":
+			principal, err = s.service.AuthenticateAPIKey(r.Context(), key)
+		case bearer && strings.Count(token, ".") == 2 && s.verifier.Enabled():
+			principal, err = s.verifier.Verify(token)
+		case bearer && token != "":
+			principal, err = s.service.AuthenticateAPIKey(r.Context(), token)
+		default:
+			err = auth.ErrUnauthenticated
+		}
+
+		if err == nil {
+			principal, err = s.policy.Resolve(r.Context(), principal)
+		}
+
+		if err != nil {
+			p := newProblem(r, http.StatusUnauthorized, CodeUnauthorized, UnauthorizedError)
+
+			if isAuthError(err) {
+				s.logger.Debug(op, slog.String("error", err.Error()))
+			} else {
+				p = newProblem(r, http.StatusInternalServerError, CodeInternal, InternalError)
+
+				s.logger.Error(op, slog.String("error", err.Error()))
+			}
+
+			w.Header().Add("WWW-Authenticate", `Bearer realm="time-tracker"`)
+			writeProblem(w, p.Status, p)
+
+			return
+		}
+
+		ctx := auth.WithPrincipal(r.Context(), principal)
+		ctx = audit.WithActor(ctx, audit.Actor{
+			Subject:   principal.Subject,
+			UserID:    principal.UserID,
+			RequestID: middleware.GetReqID(ctx),
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// allow rejects the request unless check permits the authenticated principal.
+func (s *Server) allow(check func(auth.Principal) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "server.Server.allow"
+
+			if err := check(principal(r)); err != nil {
+				s.denied(w, r, op, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (s *Server) denied(w http.ResponseWriter, r *http.Request, op string, err error) {
+	p := newProblem(r, http.StatusForbidden, CodeForbidden, ForbiddenError)
+
+	if !errors.Is(err, policy.ErrForbidden) {
+		p = newProblem(r, http.StatusInternalServerError, CodeInternal, InternalError)
+
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	writeProblem(w, p.Status, p)
+}
+
+func principal(r *http.Request) auth.Principal {
+	principal, _ := auth.FromContext(r.Context())
+
+	return principal
+}
+
+func isAuthError(err error) bool {
+	for _, target := range []error{
+		auth.ErrUnauthenticated,
+		auth.ErrMalformedToken,
+		auth.ErrUnsupportedAlg,
+		auth.ErrInvalidSignature,
+		auth.ErrTokenExpired,
+		auth.ErrTokenNotYetValid,
+		auth.ErrInvalidIssuer,
+		auth.ErrInvalidAudience,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// @Summary add api key
+// @Tags admin
+// @Description issue an api key, the plain key is only returned once
+// @Accept json
+// @Produce json
+// @Param request body dto.APIKeyRequest true "request body"
+// @Success 201 {object} dto.APIKeyCreatedResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /admin/api-keys [post]
+func (s *Server) addAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.addAPIKeyHandler"
+
+	var req dto.APIKeyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	key, err := s.service.AddAPIKey(r.Context(), req)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(key)
+	}
+}
+
+// @Summary get api keys
+// @Tags admin
+// @Description get issued api keys, including revoked ones
+// @Accept json
+// @Produce json
+// @Success 200 {array} dto.APIKey
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /admin/api-keys [get]
+func (s *Server) getAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getAPIKeysHandler"
+
+	keys, err := s.service.GetAPIKeys(r.Context())
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(keys)
+	}
+}
+
+// @Summary revoke api key
+// @Tags admin
+// @Description revoke api key
+// @Accept json
+// @Produce json
+// @Param key path string true "api key id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /admin/api-keys/{key} [delete]
+func (s *Server) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.revokeAPIKeyHandler"
+
+	keyID := chi.URLParam(r, "key")
+
+	if err := s.service.RevokeAPIKey(r.Context(), keyID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// @Summary get info cache stats
+// @Tags admin
+// @Description get hit ratio of the people info cache since start
+// @Produce json
+// @Success 200 {object} dto.InfoCacheStats
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /admin/info-cache [get]
+func (s *Server) infoCacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s.cache.Stats())
+}
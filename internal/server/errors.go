@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/service"
+	"github.com/njslxve/time-tracker-service/pkg/validate"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemType prefixes the code of a problem to make its type URI.
+const problemType = "urn:time-tracker:problem:"
+
+// Codes of the problems the server answers with on its own. Failures of the
+// service carry the code of their domain error.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeInternal             = "internal"
+)
+
+// newProblem describes a failure of the request r.
+func newProblem(r *http.Request, status int, code string, detail string) dto.Problem {
+	return dto.Problem{
+		Type:      problemType + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: middleware.GetReqID(r.Context()),
+		Code:      code,
+	}
+}
+
+// writeProblem answers with problem, a dto.Problem or a problem extended with
+// members of its own.
+func writeProblem(w http.ResponseWriter, status int, problem any) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// failed answers with the problem for a failure of the service. Failures the
+// client caused are logged at debug level, all others as errors.
+func (s *Server) failed(w http.ResponseWriter, r *http.Request, op string, err error) {
+	p := serviceProblem(r, err)
+
+	if p.Status >= http.StatusInternalServerError {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	} else {
+		s.logger.Debug(op, slog.String("error", err.Error()))
+	}
+
+	writeProblem(w, p.Status, p)
+}
+
+// badRequest answers for a request the server could not read.
+func (s *Server) badRequest(w http.ResponseWriter, r *http.Request, op string, err error, detail string) {
+	s.logger.Debug(op, slog.String("error", err.Error()))
+
+	writeProblem(w, http.StatusBadRequest, newProblem(r, http.StatusBadRequest, CodeBadRequest, detail))
+}
+
+// invalid answers for a request whose fields broke their validation rules,
+// listing each of them.
+func (s *Server) invalid(w http.ResponseWriter, r *http.Request, op string, err error) {
+	s.logger.Debug(op, slog.String("error", err.Error()))
+
+	p := newProblem(r, http.StatusUnprocessableEntity, CodeValidation, "request has invalid fields")
+
+	var errs validate.Errors
+	if errors.As(err, &errs) {
+		for _, field := range errs {
+			p.Errors = append(p.Errors, dto.FieldError{Field: field.Field, Message: field.Message})
+		}
+	}
+
+	writeProblem(w, p.Status, p)
+}
+
+// serviceProblem turns err into the problem to answer with. Domain errors
+// keep their code and message, any other error is internal and its details
+// are not shown.
+func serviceProblem(r *http.Request, err error) dto.Problem {
+	domain, ok := service.AsError(err)
+	if !ok {
+		return newProblem(r, http.StatusInternalServerError, CodeInternal, InternalError)
+	}
+
+	var status int
+
+	switch {
+	case errors.Is(err, service.ErrImportTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case domain.Kind == service.ErrNotFound:
+		status = http.StatusNotFound
+	case domain.Kind == service.ErrAlreadyExists, domain.Kind == service.ErrConflict:
+		status = http.StatusConflict
+	case domain.Kind == service.ErrValidation:
+		status = http.StatusUnprocessableEntity
+	case domain.Kind == service.ErrForbidden:
+		status = http.StatusForbidden
+	case domain.Kind == service.ErrUpstreamBadGateway:
+		status = http.StatusBadGateway
+	case domain.Kind == service.ErrUpstreamUnavailable:
+		status = http.StatusServiceUnavailable
+	case domain.Kind == service.ErrUpstreamTimeout:
+		status = http.StatusGatewayTimeout
+	default:
+		return newProblem(r, http.StatusInternalServerError, CodeInternal, InternalError)
+	}
+
+	p := newProblem(r, status, domain.Code, domain.Message)
+	p.Errors = domain.Fields
+
+	return p
+}
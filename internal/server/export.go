@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/pkg/xlsx"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	csvContentType  = "text/csv"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var timesheetHeader = []string{"user_id", "surname", "name", "patronymic", "task_id", "task_title", "project", "start_time", "end_time", "duration_minutes", "duration"}
+
+type timesheetWriter interface {
+	Write(dto.TimesheetRow) error
+	Close() error
+}
+
+type csvTimesheetWriter struct {
+	w *csv.Writer
+}
+
+func newCSVTimesheetWriter(w http.ResponseWriter) (*csvTimesheetWriter, error) {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(timesheetHeader); err != nil {
+		return nil, err
+	}
+
+	return &csvTimesheetWriter{w: cw}, nil
+}
+
+func (c *csvTimesheetWriter) Write(row dto.TimesheetRow) error {
+	return c.w.Write([]string{
+		strconv.Itoa(row.UserID),
+		csvText(row.Surname),
+		csvText(row.Name),
+		csvText(row.Patronymic),
+		csvText(row.TaskID),
+		csvText(row.Title),
+		csvText(row.ProjectName),
+		row.StartTime.Format(time.RFC3339),
+		row.EndTime.Format(time.RFC3339),
+		strconv.Itoa(row.Minutes),
+		row.Duration,
+	})
+}
+
+// csvText keeps a text cell from being taken for a formula when the file is
+// opened in a spreadsheet, by quoting it like spreadsheets do.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func (c *csvTimesheetWriter) Close() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+type xlsxTimesheetWriter struct {
+	w *xlsx.Writer
+}
+
+func newXLSXTimesheetWriter(w http.ResponseWriter) (*xlsxTimesheetWriter, error) {
+	xw, err := xlsx.NewWriter(w, "timesheet")
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]any, 0, len(timesheetHeader))
+	for _, column := range timesheetHeader {
+		header = append(header, column)
+	}
+
+	if err := xw.WriteRow(header...); err != nil {
+		return nil, err
+	}
+
+	return &xlsxTimesheetWriter{w: xw}, nil
+}
+
+func (x *xlsxTimesheetWriter) Write(row dto.TimesheetRow) error {
+	return x.w.WriteRow(
+		row.UserID,
+		row.Surname,
+		row.Name,
+		row.Patronymic,
+		row.TaskID,
+		row.Title,
+		row.ProjectName,
+		row.StartTime,
+		row.EndTime,
+		row.Minutes,
+		row.Duration,
+	)
+}
+
+func (x *xlsxTimesheetWriter) Close() error {
+	return x.w.Close()
+}
+
+func exportFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "":
+	case FormatJSON, FormatCSV, FormatXLSX:
+		return format, nil
+	default:
+		return "", errors.New("format must be one of json, csv, xlsx")
+	}
+
+	accept := r.Header.Get("Accept")
+
+	switch {
+	case strings.Contains(accept, xlsxContentType):
+		return FormatXLSX, nil
+	case strings.Contains(accept, csvContentType):
+		return FormatCSV, nil
+	default:
+		return FormatJSON, nil
+	}
+}
+
+func (s *Server) exportTimesheet(w http.ResponseWriter, r *http.Request, op string, format string, opts entity.TimesheetOptions) {
+	var (
+		tw  timesheetWriter
+		err error
+	)
+
+	// The file is streamed; cut off by the write timeout it would arrive
+	// truncated after a successful status.
+	s.extendDeadlines(w, op)
+
+	switch format {
+	case FormatCSV:
+		w.Header().Add("Content-Type", csvContentType)
+		w.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="timesheet.%s"`, format))
+		w.WriteHeader(http.StatusOK)
+
+		tw, err = newCSVTimesheetWriter(w)
+	case FormatXLSX:
+		w.Header().Add("Content-Type", xlsxContentType)
+		w.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="timesheet.%s"`, format))
+		w.WriteHeader(http.StatusOK)
+
+		tw, err = newXLSXTimesheetWriter(w)
+	}
+
+	if err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+
+		return
+	}
+
+	if err := s.service.Timesheet(r.Context(), opts, tw.Write); err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	if err := tw.Close(); err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+}
+
+func intervalStart(interval string) time.Time {
+	days, _ := strconv.Atoi(interval)
+	if days == 0 {
+		return time.Time{}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	return today.AddDate(0, 0, -days)
+}
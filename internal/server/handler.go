@@ -3,14 +3,19 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/njslxve/time-tracker-service/internal/model/dto"
 	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+	"github.com/njslxve/time-tracker-service/internal/service"
+	"github.com/njslxve/time-tracker-service/pkg/ical"
 )
 
 const (
@@ -24,10 +29,15 @@ const (
 // @Accept json
 // @Produce json
 // @Param request body dto.AddUserRequest true "request body"
-// @Success 201
-// @Failure 400 {object} dto.Error
-// @Failure 422 {object} dto.Error
-// @Failure 500 {object} dto.Error
+// @Success 202 {object} dto.AddUserResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /users/add [post]
 func (s *Server) addUserHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.addUserHandler"
@@ -36,45 +46,61 @@ func (s *Server) addUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		e := dto.Error{
-			Message: BadRequestError,
-		}
+		s.badRequest(w, r, op, err, BadRequestError)
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(e)
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
 
 		return
 	}
 
-	if !strings.Contains(req.Passport, " ") {
-		e := dto.Error{
-			Message: "passport must contain space",
-		}
+	user, err := s.service.AddUser(r.Context(), req)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(user)
+	}
+}
 
-		s.logger.Debug(op, slog.String("error", errors.New("passport must contain space").Error()))
+// @Summary get user
+// @Tags users
+// @Description get user with the status of their enrichment
+// @Accept json
+// @Produce json
+// @Param user path string true "user id"
+// @Success 200 {object} dto.User
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /users/{user} [get]
+func (s *Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getUserHandler"
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		json.NewEncoder(w).Encode(e)
+	userID := chi.URLParam(r, "user")
 
-		return
-	}
+	id, _ := strconv.Atoi(userID)
 
-	if err := s.service.AddUser(req); err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
+	if err := s.policy.ReadUser(r.Context(), principal(r), id); err != nil {
+		s.denied(w, r, op, err)
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+	user, err := s.service.GetUser(r.Context(), userID)
+	if err != nil {
+		s.failed(w, r, op, err)
 	} else {
-		w.WriteHeader(http.StatusCreated)
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(user)
 	}
 }
 
@@ -85,8 +111,15 @@ func (s *Server) addUserHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param request body dto.TaskRequest true "request body"
 // @Success 201
-// @Failure 400 {object} dto.Error
-// @Failure 500 {object} dto.Error
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.RunningTaskProblem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /tasks/start [post]
 func (s *Server) addTaskHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.addTaskHandler"
@@ -95,27 +128,40 @@ func (s *Server) addTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		e := dto.Error{
-			Message: BadRequestError,
-		}
+		s.badRequest(w, r, op, err, BadRequestError)
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(e)
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
+
+		return
 	}
 
-	if err := s.service.AddTask(req); err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
+	if err := s.policy.TrackTime(principal(r), req.UserID); err != nil {
+		s.denied(w, r, op, err)
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+	if err := s.service.AddTask(r.Context(), req); err != nil {
+		var runningErr *service.RunningTaskError
+
+		if errors.As(err, &runningErr) {
+			p := dto.RunningTaskProblem{
+				Problem: serviceProblem(r, err),
+				Task:    runningErr.Task,
+			}
+
+			s.logger.Debug(op, slog.String("error", err.Error()))
+
+			writeProblem(w, p.Status, p)
+
+			return
+		}
+
+		s.failed(w, r, op, err)
 	} else {
 		w.WriteHeader(http.StatusCreated)
 	}
@@ -128,8 +174,15 @@ func (s *Server) addTaskHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param request body dto.TaskRequest true "request body"
 // @Success 200
-// @Failure 400 {object} dto.Error
-// @Failure 500 {object} dto.Error
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /tasks/end [post]
 func (s *Server) endTaskHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.endTaskHandler"
@@ -138,27 +191,218 @@ func (s *Server) endTaskHandler(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		e := dto.Error{
-			Message: BadRequestError,
-		}
+		s.badRequest(w, r, op, err, BadRequestError)
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(e)
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
+
+		return
 	}
 
-	if err := s.service.EndTask(req); err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
+	if err := s.policy.TrackTime(principal(r), req.UserID); err != nil {
+		s.denied(w, r, op, err)
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+	if err := s.service.EndTask(r.Context(), req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary add manual task
+// @Tags tasks
+// @Description add completed task session with explicit start and end time
+// @Accept json
+// @Produce json
+// @Param request body dto.ManualTaskRequest true "request body"
+// @Success 201
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /tasks/manual [post]
+func (s *Server) addManualTaskHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.addManualTaskHandler"
+
+	var req dto.ManualTaskRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
+
+		return
+	}
+
+	if err := s.policy.TrackTime(principal(r), req.UserID); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	if err := s.service.AddManualTask(r.Context(), req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// @Summary update task session
+// @Tags tasks
+// @Description adjust start or end time of a task session
+// @Accept json
+// @Produce json
+// @Param session path string true "session id"
+// @Param request body dto.UpdateTaskRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /tasks/{session} [patch]
+func (s *Server) updateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.updateTaskHandler"
+
+	var req dto.UpdateTaskRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
+
+		return
+	}
+
+	sessionID := chi.URLParam(r, "session")
+
+	if err := s.policy.EditSession(r.Context(), principal(r), sessionID); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	if err := s.service.UpdateSession(r.Context(), sessionID, req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary pause task
+// @Tags tasks
+// @Description pause task
+// @Accept json
+// @Produce json
+// @Param request body dto.TaskRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /tasks/pause [post]
+func (s *Server) pauseTaskHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.pauseTaskHandler"
+
+	var req dto.TaskRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
+
+		return
+	}
+
+	if err := s.policy.TrackTime(principal(r), req.UserID); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	if err := s.service.PauseTask(r.Context(), req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary resume task
+// @Tags tasks
+// @Description resume task
+// @Accept json
+// @Produce json
+// @Param request body dto.TaskRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /tasks/resume [post]
+func (s *Server) resumeTaskHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.resumeTaskHandler"
+
+	var req dto.TaskRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
+
+		return
+	}
+
+	if err := s.policy.TrackTime(principal(r), req.UserID); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	if err := s.service.ResumeTask(r.Context(), req); err != nil {
+		s.failed(w, r, op, err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 	}
@@ -172,8 +416,15 @@ func (s *Server) endTaskHandler(w http.ResponseWriter, r *http.Request) {
 // @Param user path string true "user id"
 // @Param request body dto.UpdateUserRequest true "request body"
 // @Success 200
-// @Failure 400 {object} dto.Error
-// @Failure 500 {object} dto.Error
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /users/{user} [patch]
 func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.updateUserHandler"
@@ -182,29 +433,21 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		e := dto.Error{
-			Message: BadRequestError,
-		}
-
-		s.logger.Error(op, slog.String("error", err.Error()))
+		s.badRequest(w, r, op, err, BadRequestError)
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(e)
+		return
 	}
 
-	userID := chi.URLParam(r, "user")
+	if err := s.validator.Struct(req); err != nil {
+		s.invalid(w, r, op, err)
 
-	if err := s.service.UpdateUser(userID, req); err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
+		return
+	}
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+	userID := chi.URLParam(r, "user")
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+	if err := s.service.UpdateUser(r.Context(), userID, req); err != nil {
+		s.failed(w, r, op, err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 	}
@@ -212,28 +455,80 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 // @Summary delete user
 // @Tags users
-// @Description delete user
+// @Description archive user, their tracked time is kept and a running task is stopped
 // @Accept json
 // @Produce json
 // @Param user path string true "user id"
 // @Success 200
-// @Failure 500 {object} dto.Error
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /users/{user} [delete]
 func (s *Server) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.deleteUserHandler"
 
 	userID := chi.URLParam(r, "user")
 
-	if err := s.service.DeleteUser(userID); err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
+	if err := s.service.DeleteUser(r.Context(), userID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+// @Summary restore user
+// @Tags users
+// @Description restore archived user
+// @Accept json
+// @Produce json
+// @Param user path string true "user id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /users/{user}/restore [post]
+func (s *Server) restoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.restoreUserHandler"
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+	userID := chi.URLParam(r, "user")
+
+	if err := s.service.RestoreUser(r.Context(), userID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary purge user
+// @Tags users
+// @Description permanently remove archived user with all their tracked time, e.g. on a GDPR erasure request
+// @Accept json
+// @Produce json
+// @Param user path string true "user id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /users/{user}/purge [post]
+func (s *Server) purgeUserHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.purgeUserHandler"
+
+	userID := chi.URLParam(r, "user")
+
+	if err := s.service.PurgeUser(r.Context(), userID); err != nil {
+		s.failed(w, r, op, err)
 	} else {
 		w.WriteHeader(http.StatusOK)
 	}
@@ -243,11 +538,17 @@ func (s *Server) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 // @Tags tasks
 // @Description get tasks
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param user path string true "user id"
 // @Param interval query string false "interval"
+// @Param format query string false "json, csv or xlsx; overrides the Accept header"
 // @Success 200 {array} dto.TaskResponse
-// @Failure 500 {object} dto.Error
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /tasks/{user} [get]
 func (s *Server) getTasksHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.getTasksHandler"
@@ -255,17 +556,34 @@ func (s *Server) getTasksHandler(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "user")
 	interval := r.URL.Query().Get("interval")
 
-	tasks, err := s.service.GetTasks(userID, interval)
+	id, _ := strconv.Atoi(userID)
+
+	if err := s.policy.ReadUser(r.Context(), principal(r), id); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	format, err := exportFormat(r)
 	if err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
+		s.badRequest(w, r, op, err, err.Error())
 
-		s.logger.Error(op, slog.String("error", err.Error()))
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+	if format != FormatJSON {
+		s.exportTimesheet(w, r, op, format, entity.TimesheetOptions{
+			UserID: id,
+			From:   intervalStart(interval),
+			To:     time.Now(),
+		})
+
+		return
+	}
+
+	tasks, err := s.service.GetTasks(r.Context(), userID, interval)
+	if err != nil {
+		s.failed(w, r, op, err)
 	} else {
 		w.Header().Add("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -273,50 +591,489 @@ func (s *Server) getTasksHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary get task totals
+// @Tags tasks
+// @Description get tasks aggregated across sessions
+// @Accept json
+// @Produce json
+// @Param user path string true "user id"
+// @Param interval query string false "interval"
+// @Success 200 {array} dto.TaskTotalResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /tasks/{user}/totals [get]
+func (s *Server) getTaskTotalsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getTaskTotalsHandler"
+
+	userID := chi.URLParam(r, "user")
+	interval := r.URL.Query().Get("interval")
+
+	id, _ := strconv.Atoi(userID)
+
+	if err := s.policy.ReadUser(r.Context(), principal(r), id); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	totals, err := s.service.GetTaskTotals(r.Context(), userID, interval)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(totals)
+	}
+}
+
+// @Summary get user report
+// @Tags reports
+// @Description get user workload aggregated by task, project or period
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param user path string true "user id"
+// @Param from query string false "period start, RFC3339 (default: 30 days before to)"
+// @Param to query string false "period end, RFC3339 (default: now)"
+// @Param group_by query string false "task, project, day, week or month (default: task)"
+// @Param format query string false "json, csv or xlsx; overrides the Accept header"
+// @Success 200 {object} dto.UserReportResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /reports/users/{user} [get]
+func (s *Server) getUserReportHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getUserReportHandler"
+
+	userID, _ := strconv.Atoi(chi.URLParam(r, "user"))
+
+	if err := s.policy.ReadUser(r.Context(), principal(r), userID); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	opts, err := reportOptions(r)
+	if err != nil {
+		s.badRequest(w, r, op, err, err.Error())
+
+		return
+	}
+
+	opts.UserID = userID
+
+	format, err := exportFormat(r)
+	if err != nil {
+		s.badRequest(w, r, op, err, err.Error())
+
+		return
+	}
+
+	if format != FormatJSON {
+		s.exportTimesheet(w, r, op, format, entity.TimesheetOptions{
+			UserID: opts.UserID,
+			From:   opts.From,
+			To:     opts.To,
+		})
+
+		return
+	}
+
+	report, err := s.service.GetUserReport(r.Context(), opts)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// @Summary get summary report
+// @Tags reports
+// @Description get time tracked by every user for a period
+// @Accept json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param from query string false "period start, RFC3339 (default: 30 days before to)"
+// @Param to query string false "period end, RFC3339 (default: now)"
+// @Param name query string false "name"
+// @Param surname query string false "surname"
+// @Param patronymic query string false "patronymic"
+// @Param adress query string false "adress"
+// @Param include_deleted query bool false "include users archived before the period"
+// @Param sort query string false "asc or desc by total time (default: desc)"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Param format query string false "json, csv or xlsx; overrides the Accept header"
+// @Success 200 {object} dto.SummaryReportResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /reports/summary [get]
+func (s *Server) getSummaryReportHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getSummaryReportHandler"
+
+	managerID, err := s.policy.ReadTeam(principal(r))
+	if err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	opts, err := summaryOptions(r)
+	if err != nil {
+		s.badRequest(w, r, op, err, err.Error())
+
+		return
+	}
+
+	opts.Filter.ManagerID = managerID
+
+	format, err := exportFormat(r)
+	if err != nil {
+		s.badRequest(w, r, op, err, err.Error())
+
+		return
+	}
+
+	if format != FormatJSON {
+		s.exportTimesheet(w, r, op, format, entity.TimesheetOptions{
+			From:   opts.From,
+			To:     opts.To,
+			Filter: opts.Filter,
+		})
+
+		return
+	}
+
+	report, err := s.service.GetSummaryReport(r.Context(), opts)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// @Summary rotate calendar token
+// @Tags users
+// @Description issue a new secret token for the user's calendar feed, revoking the previous one
+// @Accept json
+// @Produce json
+// @Param user path string true "user id"
+// @Success 200 {object} dto.CalendarTokenResponse
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /users/{user}/calendar/token [post]
+func (s *Server) rotateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.rotateCalendarTokenHandler"
+
+	userID := chi.URLParam(r, "user")
+	id, _ := strconv.Atoi(userID)
+
+	if err := s.policy.TrackTime(principal(r), id); err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	token, err := s.service.RotateCalendarToken(r.Context(), userID)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(token)
+	}
+}
+
+// @Summary get calendar
+// @Tags users
+// @Description iCalendar feed of the user's tracked sessions
+// @Produce text/calendar
+// @Param user path string true "user id"
+// @Param token query string true "calendar token"
+// @Param interval query string false "interval"
+// @Success 200
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Router       /users/{user}/calendar.ics [get]
+func (s *Server) calendarHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.calendarHandler"
+
+	userID := chi.URLParam(r, "user")
+	interval := r.URL.Query().Get("interval")
+
+	if err := s.service.CheckCalendarToken(r.Context(), userID, r.URL.Query().Get("token")); err != nil {
+		s.failed(w, r, op, err)
+
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	cw, err := ical.NewWriter(w, fmt.Sprintf("Time tracker: user %s", userID))
+	if err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+
+		return
+	}
+
+	now := time.Now()
+
+	err = s.service.Calendar(r.Context(), userID, interval, func(row dto.TimesheetRow) error {
+		event := ical.Event{
+			UID:         row.SessionID + "@time-tracker",
+			Start:       row.StartTime,
+			End:         row.EndTime,
+			Stamp:       now,
+			Summary:     row.Title,
+			Description: "Duration: " + row.Duration,
+			Status:      "CONFIRMED",
+		}
+
+		if row.EndTime.IsZero() {
+			event.End = now
+			event.Summary = row.Title + " (in progress)"
+			event.Description = ""
+			event.Status = "TENTATIVE"
+		}
+
+		return cw.WriteEvent(event)
+	})
+	if err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	if err := cw.Close(); err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+}
+
 // @Summary get users
 // @Tags users
 // @Description get users
 // @Accept json
 // @Produce json
 // @Param name query string false "name"
+// @Param name_match query string false "how name matches: exact (default), prefix, contains or fuzzy"
 // @Param surname query string false "surname"
+// @Param surname_match query string false "how surname matches: exact (default), prefix, contains or fuzzy"
+// @Param patronymic query string false "patronymic"
+// @Param patronymic_match query string false "how patronymic matches: exact (default), prefix, contains or fuzzy"
 // @Param adress query string false "adress"
+// @Param adress_match query string false "how adress matches: contains (default), exact, prefix or fuzzy"
+// @Param q query string false "full-text and similarity search across names and adress"
+// @Param passport_series query string false "passport series, 4 digits"
+// @Param sort query string false "user_id (default), name, surname or relevance (default with q)"
+// @Param order query string false "asc or desc, desc by default for relevance"
 // @Param limit query int false "limit"
-// @Param next_page query string false "next_page"
+// @Param include_deleted query bool false "include archived users"
+// @Param next_page query string false "cursor of the next page"
+// @Param prev_page query string false "cursor of the previous page"
 // @Success 200 {object} dto.GetUsersResponse
-// @Failure 500 {object} dto.Error
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router       /users [get]
 func (s *Server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "server.Server.getUsersHandler"
 
-	filterOps := entity.FilterOptions{
-		Name:       r.URL.Query().Get("name"),
-		Surname:    r.URL.Query().Get("surname"),
-		Patronymic: r.URL.Query().Get("patronymic"),
-		Adress:     r.URL.Query().Get("adress"),
+	managerID, err := s.policy.ReadTeam(principal(r))
+	if err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	filterOps, err := userFilterOptions(r)
+	if err == nil {
+		err = checkUserSort(r, filterOps)
 	}
 
+	if err != nil {
+		s.badRequest(w, r, op, err, err.Error())
+
+		return
+	}
+
+	filterOps.ManagerID = managerID
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 
 	paginationOpts := entity.PaginationOptions{
 		Limit: limit,
+		Sort:  r.URL.Query().Get("sort"),
+		Order: r.URL.Query().Get("order"),
 		Next:  r.URL.Query().Get("next_page"),
+		Prev:  r.URL.Query().Get("prev_page"),
 	}
 
-	users, err := s.service.GetUsers(filterOps, paginationOpts)
+	users, err := s.service.GetUsers(r.Context(), filterOps, paginationOpts)
 	if err != nil {
-		e := dto.Error{
-			Message: InternalError,
-		}
-
-		s.logger.Error(op, slog.String("error", err.Error()))
-
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(e)
+		s.failed(w, r, op, err)
 	} else {
 		w.Header().Add("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(users)
 	}
 }
+
+func reportOptions(r *http.Request) (entity.ReportOptions, error) {
+	from, to, err := reportPeriod(r)
+	if err != nil {
+		return entity.ReportOptions{}, err
+	}
+
+	opts := entity.ReportOptions{
+		From:    from,
+		To:      to,
+		GroupBy: r.URL.Query().Get("group_by"),
+	}
+
+	switch opts.GroupBy {
+	case "":
+		opts.GroupBy = entity.GroupByTask
+	case entity.GroupByTask, entity.GroupByProject, entity.GroupByDay, entity.GroupByWeek, entity.GroupByMonth:
+	default:
+		return entity.ReportOptions{}, errors.New("group_by must be one of task, project, day, week, month")
+	}
+
+	return opts, nil
+}
+
+func summaryOptions(r *http.Request) (entity.SummaryOptions, error) {
+	from, to, err := reportPeriod(r)
+	if err != nil {
+		return entity.SummaryOptions{}, err
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	filter, err := userFilterOptions(r)
+	if err != nil {
+		return entity.SummaryOptions{}, err
+	}
+
+	opts := entity.SummaryOptions{
+		From:   from,
+		To:     to,
+		Filter: filter,
+		Sort:   r.URL.Query().Get("sort"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	switch opts.Sort {
+	case "":
+		opts.Sort = entity.SortDesc
+	case entity.SortAsc, entity.SortDesc:
+	default:
+		return entity.SummaryOptions{}, errors.New("sort must be one of asc, desc")
+	}
+
+	return opts, nil
+}
+
+// userFilterOptions reads the user filter shared by the user list and the
+// reports.
+func userFilterOptions(r *http.Request) (entity.FilterOptions, error) {
+	query := r.URL.Query()
+
+	opts := entity.FilterOptions{
+		Name:            query.Get("name"),
+		NameMatch:       query.Get("name_match"),
+		Surname:         query.Get("surname"),
+		SurnameMatch:    query.Get("surname_match"),
+		Patronymic:      query.Get("patronymic"),
+		PatronymicMatch: query.Get("patronymic_match"),
+		Adress:          query.Get("adress"),
+		AdressMatch:     query.Get("adress_match"),
+		Query:           strings.TrimSpace(query.Get("q")),
+		PassportSeries:  query.Get("passport_series"),
+	}
+
+	opts.IncludeDeleted, _ = strconv.ParseBool(query.Get("include_deleted"))
+
+	for _, mode := range []string{opts.NameMatch, opts.SurnameMatch, opts.PatronymicMatch, opts.AdressMatch} {
+		switch mode {
+		case "", entity.MatchExact, entity.MatchPrefix, entity.MatchContains, entity.MatchFuzzy:
+		default:
+			return entity.FilterOptions{}, errors.New("name_match, surname_match, patronymic_match and adress_match must be one of exact, prefix, contains, fuzzy")
+		}
+	}
+
+	if opts.PassportSeries != "" && !passport.ValidSeries(opts.PassportSeries) {
+		return entity.FilterOptions{}, errors.New("passport_series must be 4 digits")
+	}
+
+	return opts, nil
+}
+
+func checkUserSort(r *http.Request, filter entity.FilterOptions) error {
+	switch r.URL.Query().Get("sort") {
+	case "", entity.SortUserID, entity.SortName, entity.SortSurname:
+	case entity.SortRelevance:
+		if filter.Query == "" {
+			return errors.New("sort by relevance needs q")
+		}
+	default:
+		return errors.New("sort must be one of user_id, name, surname, relevance")
+	}
+
+	switch r.URL.Query().Get("order") {
+	case "", entity.SortAsc, entity.SortDesc:
+	default:
+		return errors.New("order must be one of asc, desc")
+	}
+
+	return nil
+}
+
+func reportPeriod(r *http.Request) (time.Time, time.Time, error) {
+	to := time.Now()
+
+	if param := r.URL.Query().Get("to"); param != "" {
+		t, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be an RFC3339 timestamp")
+		}
+
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -30)
+
+	if param := r.URL.Query().Get("from"); param != "" {
+		t, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be an RFC3339 timestamp")
+		}
+
+		from = t
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+
+	return from, to, nil
+}
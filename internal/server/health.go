@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+)
+
+const healthTimeout = 2 * time.Second
+
+// @Summary health check
+// @Tags health
+// @Description check that the service is up and can reach its database
+// @Produce json
+// @Success 200 {object} dto.Health
+// @Failure 503 {object} dto.Health
+// @Router       /health [get]
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.healthHandler"
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	if err := s.service.Ping(ctx); err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(dto.Health{Status: "unavailable"})
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(dto.Health{Status: "ok"})
+	}
+}
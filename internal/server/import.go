@@ -0,0 +1,243 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/service"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// @Summary import users
+// @Tags users
+// @Description create users from a CSV file with a passport column, from NDJSON lines of add user requests or from a JSON array of them,
+// @Description every row is validated and looked up in the people info service, dry_run only reports what would be created
+// @Accept text/csv,application/x-ndjson,application/json
+// @Produce json
+// @Param dry_run query bool false "validate and enrich without creating users"
+// @Success 200 {object} dto.ImportReport
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 413 {object} dto.Problem
+// @Failure 415 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /users/import [post]
+func (s *Server) importUsersHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.importUsersHandler"
+
+	dryRun := false
+
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			s.badRequest(w, r, op, err, "dry_run must be true or false")
+
+			return
+		}
+	}
+
+	// Every row is looked up in the people info service, which takes longer
+	// than the server lets connections read and write.
+	s.extendDeadlines(w, op)
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.ImportMaxBytes)
+
+	passports, status, err := readImport(r, s.cfg.ImportMaxRows)
+	if errors.Is(err, service.ErrImportTooLarge) {
+		s.failed(w, r, op, err)
+
+		return
+	}
+
+	if err != nil {
+		code := CodeBadRequest
+		if status == http.StatusUnsupportedMediaType {
+			code = CodeUnsupportedMediaType
+		}
+
+		s.logger.Debug(op, slog.String("error", err.Error()))
+
+		writeProblem(w, status, newProblem(r, status, code, err.Error()))
+
+		return
+	}
+
+	report, err := s.service.ImportUsers(r.Context(), passports, dryRun)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// readImport reads the passports of an import body in the format given by
+// its Content-Type. It stops at the first record past maxRows, and bodies
+// cut off by http.MaxBytesReader are too large as well. The status goes with
+// the error.
+func readImport(r *http.Request, maxRows int) ([]string, int, error) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var (
+		passports []string
+		err       error
+	)
+
+	switch contentType {
+	case csvContentType:
+		passports, err = readImportCSV(r.Body, maxRows)
+	case ndjsonContentType:
+		passports, err = readImportNDJSON(r.Body, maxRows)
+	case "application/json":
+		passports, err = readImportJSON(r.Body, maxRows)
+	default:
+		return nil, http.StatusUnsupportedMediaType, errors.New("content type must be text/csv, application/x-ndjson or application/json")
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, http.StatusRequestEntityTooLarge, service.ErrImportTooLarge
+	}
+
+	if errors.Is(err, service.ErrImportTooLarge) {
+		return nil, http.StatusRequestEntityTooLarge, err
+	}
+
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	return passports, http.StatusOK, nil
+}
+
+// readImportCSV takes the passport column if the first record is a header
+// naming one, and the first column otherwise.
+func readImportCSV(body io.Reader, maxRows int) ([]string, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var (
+		passports []string
+		column    = 0
+	)
+
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 {
+			if i := headerColumn(record); i >= 0 {
+				column = i
+
+				continue
+			}
+		}
+
+		if column >= len(record) {
+			return nil, fmt.Errorf("line %d: passport column is missing", line)
+		}
+
+		if len(passports) == maxRows {
+			return nil, service.ErrImportTooLarge
+		}
+
+		passports = append(passports, record[column])
+	}
+
+	return passports, nil
+}
+
+func headerColumn(record []string) int {
+	for i, field := range record {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "passport", "passportnumber":
+			return i
+		}
+	}
+
+	return -1
+}
+
+// readImportNDJSON reads one add user request per line.
+func readImportNDJSON(body io.Reader, maxRows int) ([]string, error) {
+	dec := json.NewDecoder(body)
+
+	var passports []string
+
+	for line := 1; ; line++ {
+		var req dto.AddUserRequest
+
+		err := dec.Decode(&req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", line, err)
+		}
+
+		if len(passports) == maxRows {
+			return nil, service.ErrImportTooLarge
+		}
+
+		passports = append(passports, req.Passport)
+	}
+
+	return passports, nil
+}
+
+// readImportJSON reads an array of add user requests one element at a time.
+func readImportJSON(body io.Reader, maxRows int) ([]string, error) {
+	dec := json.NewDecoder(body)
+
+	if token, err := dec.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('[') {
+		return nil, errors.New("body must be an array of add user requests")
+	}
+
+	var passports []string
+
+	for record := 1; dec.More(); record++ {
+		var req dto.AddUserRequest
+
+		if err := dec.Decode(&req); err != nil {
+			return nil, fmt.Errorf("record %d: %w", record, err)
+		}
+
+		if len(passports) == maxRows {
+			return nil, service.ErrImportTooLarge
+		}
+
+		passports = append(passports, req.Passport)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return passports, nil
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+)
+
+// @Summary add project
+// @Tags projects
+// @Description add project
+// @Accept json
+// @Produce json
+// @Param request body dto.ProjectRequest true "request body"
+// @Success 201 {object} dto.Project
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /projects [post]
+func (s *Server) addProjectHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.addProjectHandler"
+
+	var req dto.ProjectRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	project, err := s.service.AddProject(r.Context(), req)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(project)
+	}
+}
+
+// @Summary get projects
+// @Tags projects
+// @Description get projects
+// @Accept json
+// @Produce json
+// @Success 200 {array} dto.Project
+// @Failure 401 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /projects [get]
+func (s *Server) getProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getProjectsHandler"
+
+	projects, err := s.service.GetProjects(r.Context())
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(projects)
+	}
+}
+
+// @Summary get project
+// @Tags projects
+// @Description get project
+// @Accept json
+// @Produce json
+// @Param project path string true "project id"
+// @Success 200 {object} dto.Project
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /projects/{project} [get]
+func (s *Server) getProjectHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getProjectHandler"
+
+	projectID := chi.URLParam(r, "project")
+
+	project, err := s.service.GetProject(r.Context(), projectID)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(project)
+	}
+}
+
+// @Summary update project
+// @Tags projects
+// @Description update project
+// @Accept json
+// @Produce json
+// @Param project path string true "project id"
+// @Param request body dto.ProjectRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /projects/{project} [patch]
+func (s *Server) updateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.updateProjectHandler"
+
+	var req dto.ProjectRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	projectID := chi.URLParam(r, "project")
+
+	if err := s.service.UpdateProject(r.Context(), projectID, req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary delete project
+// @Tags projects
+// @Description delete project, its task definitions are kept without a project
+// @Accept json
+// @Produce json
+// @Param project path string true "project id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /projects/{project} [delete]
+func (s *Server) deleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.deleteProjectHandler"
+
+	projectID := chi.URLParam(r, "project")
+
+	if err := s.service.DeleteProject(r.Context(), projectID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary add task definition
+// @Tags task definitions
+// @Description add task definition
+// @Accept json
+// @Produce json
+// @Param request body dto.AddTaskDefinitionRequest true "request body"
+// @Success 201
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /task-definitions [post]
+func (s *Server) addTaskDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.addTaskDefinitionHandler"
+
+	var req dto.AddTaskDefinitionRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	if err := s.service.AddTaskDefinition(r.Context(), req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// @Summary get task definitions
+// @Tags task definitions
+// @Description get task definitions
+// @Accept json
+// @Produce json
+// @Param project query string false "project id"
+// @Success 200 {array} dto.TaskDefinition
+// @Failure 401 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /task-definitions [get]
+func (s *Server) getTaskDefinitionsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getTaskDefinitionsHandler"
+
+	projectID := r.URL.Query().Get("project")
+
+	definitions, err := s.service.GetTaskDefinitions(r.Context(), projectID)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(definitions)
+	}
+}
+
+// @Summary get task definition
+// @Tags task definitions
+// @Description get task definition
+// @Accept json
+// @Produce json
+// @Param task path string true "task id"
+// @Success 200 {object} dto.TaskDefinition
+// @Failure 401 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /task-definitions/{task} [get]
+func (s *Server) getTaskDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getTaskDefinitionHandler"
+
+	taskID := chi.URLParam(r, "task")
+
+	definition, err := s.service.GetTaskDefinition(r.Context(), taskID)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(definition)
+	}
+}
+
+// @Summary update task definition
+// @Tags task definitions
+// @Description update task definition, project_id 0 detaches it from its project
+// @Accept json
+// @Produce json
+// @Param task path string true "task id"
+// @Param request body dto.UpdateTaskDefinitionRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /task-definitions/{task} [patch]
+func (s *Server) updateTaskDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.updateTaskDefinitionHandler"
+
+	var req dto.UpdateTaskDefinitionRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	taskID := chi.URLParam(r, "task")
+
+	if err := s.service.UpdateTaskDefinition(r.Context(), taskID, req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary delete task definition
+// @Tags task definitions
+// @Description delete task definition that has no tracked sessions
+// @Accept json
+// @Produce json
+// @Param task path string true "task id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /task-definitions/{task} [delete]
+func (s *Server) deleteTaskDefinitionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.deleteTaskDefinitionHandler"
+
+	taskID := chi.URLParam(r, "task")
+
+	if err := s.service.DeleteTaskDefinition(r.Context(), taskID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
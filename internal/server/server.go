@@ -13,43 +13,171 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/njslxve/time-tracker-service/internal/auth"
+	"github.com/njslxve/time-tracker-service/internal/cache"
 	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+	"github.com/njslxve/time-tracker-service/internal/policy"
 	"github.com/njslxve/time-tracker-service/internal/service"
+	"github.com/njslxve/time-tracker-service/pkg/validate"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// requestTimeout bounds the work done for a request. Connections are given
+// less time to read and write, which requests known to take longer extend.
+const requestTimeout = 30 * time.Second
+
 type Server struct {
-	cfg     *config.Config
-	logger  *slog.Logger
-	service *service.Service
+	cfg       *config.Config
+	logger    *slog.Logger
+	service   *service.Service
+	verifier  *auth.Verifier
+	policy    *policy.Policy
+	cache     *cache.Cache
+	validator *validate.Validator
 }
 
-func New(cfg *config.Config, logger *slog.Logger, service *service.Service) *Server {
+func New(cfg *config.Config, logger *slog.Logger, service *service.Service, verifier *auth.Verifier, policy *policy.Policy, cache *cache.Cache) *Server {
 	return &Server{
-		cfg:     cfg,
-		logger:  logger,
-		service: service,
+		cfg:       cfg,
+		logger:    logger,
+		service:   service,
+		verifier:  verifier,
+		policy:    policy,
+		cache:     cache,
+		validator: newValidator(),
 	}
 }
 
+// newValidator knows the rules of the request DTOs, passport among them.
+func newValidator() *validate.Validator {
+	v := validate.New()
+
+	v.Rule("passport", func(value any) error {
+		number, _ := value.(string)
+
+		_, err := passport.Normalize(number)
+
+		return err
+	})
+
+	return v
+}
+
 func (s *Server) Start() {
 	r := chi.NewRouter()
 
-	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Timeout(requestTimeout))
 
 	r.Route("/users", func(r chi.Router) {
-		r.Get("/", s.getUsersHandler)
-		r.Post("/add", s.addUserHandler)
-		r.Patch("/{user}", s.updateUserHandler)
-		r.Delete("/{user}", s.deleteUserHandler)
+		r.Get("/{user}/calendar.ics", s.calendarHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.authenticate)
+
+			r.Get("/", s.getUsersHandler)
+			r.Get("/{user}", s.getUserHandler)
+			r.Post("/{user}/calendar/token", s.rotateCalendarTokenHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.allow(s.policy.Admin))
+
+				r.Post("/add", s.addUserHandler)
+				r.Post("/import", s.importUsersHandler)
+				r.Patch("/{user}", s.updateUserHandler)
+				r.Delete("/{user}", s.deleteUserHandler)
+				r.Post("/{user}/restore", s.restoreUserHandler)
+				r.Post("/{user}/purge", s.purgeUserHandler)
+				r.Put("/{user}/role", s.setUserRoleHandler)
+			})
+		})
 	})
 
-	r.Route("/tasks", func(r chi.Router) {
-		r.Get("/{user}", s.getTasksHandler)
-		r.Post("/start", s.addTaskHandler)
-		r.Post("/end", s.endTaskHandler)
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+
+		r.Route("/tasks", func(r chi.Router) {
+			r.Get("/{user}", s.getTasksHandler)
+			r.Get("/{user}/totals", s.getTaskTotalsHandler)
+			r.Post("/start", s.addTaskHandler)
+			r.Post("/end", s.endTaskHandler)
+			r.Post("/pause", s.pauseTaskHandler)
+			r.Post("/resume", s.resumeTaskHandler)
+			r.Post("/manual", s.addManualTaskHandler)
+			r.Patch("/{session}", s.updateTaskHandler)
+		})
+
+		r.Route("/projects", func(r chi.Router) {
+			r.Get("/", s.getProjectsHandler)
+			r.Get("/{project}", s.getProjectHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.allow(s.policy.Manager))
+
+				r.Post("/", s.addProjectHandler)
+				r.Patch("/{project}", s.updateProjectHandler)
+				r.Delete("/{project}", s.deleteProjectHandler)
+			})
+		})
+
+		r.Route("/task-definitions", func(r chi.Router) {
+			r.Get("/", s.getTaskDefinitionsHandler)
+			r.Get("/{task}", s.getTaskDefinitionHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.allow(s.policy.Manager))
+
+				r.Post("/", s.addTaskDefinitionHandler)
+				r.Patch("/{task}", s.updateTaskDefinitionHandler)
+				r.Delete("/{task}", s.deleteTaskDefinitionHandler)
+			})
+		})
+
+		r.Route("/teams", func(r chi.Router) {
+			r.Use(s.allow(s.policy.Manager))
+
+			r.Get("/", s.getTeamsHandler)
+			r.Get("/{team}", s.getTeamHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.allow(s.policy.Admin))
+
+				r.Post("/", s.addTeamHandler)
+				r.Patch("/{team}", s.updateTeamHandler)
+				r.Delete("/{team}", s.deleteTeamHandler)
+				r.Post("/{team}/members", s.addTeamMemberHandler)
+				r.Delete("/{team}/members/{user}", s.deleteTeamMemberHandler)
+			})
+		})
+
+		r.Route("/reports", func(r chi.Router) {
+			r.Get("/users/{user}", s.getUserReportHandler)
+			r.Get("/summary", s.getSummaryReportHandler)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(s.allow(s.policy.Admin))
+
+			r.Get("/api-keys", s.getAPIKeysHandler)
+			r.Post("/api-keys", s.addAPIKeyHandler)
+			r.Delete("/api-keys/{key}", s.revokeAPIKeyHandler)
+
+			r.Get("/info-cache", s.infoCacheStatsHandler)
+
+			r.Get("/sync", s.getSyncHandler)
+			r.Post("/sync", s.startSyncHandler)
+		})
+
+		r.Route("/audit", func(r chi.Router) {
+			r.Use(s.allow(s.policy.Admin))
+
+			r.Get("/", s.getAuditLogHandler)
+		})
 	})
 
+	r.Get("/health", s.healthHandler)
+
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL(fmt.Sprintf("http://%s/swagger/doc.json", s.cfg.Address)),
 	))
@@ -94,3 +222,18 @@ func (s *Server) Start() {
 
 	s.logger.Info("server shutdown")
 }
+
+// extendDeadlines lets the connection of a request read and write for as long
+// as the request may run.
+func (s *Server) extendDeadlines(w http.ResponseWriter, op string) {
+	deadline := time.Now().Add(requestTimeout)
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		s.logger.Debug(op, slog.String("error", err.Error()))
+	}
+
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		s.logger.Debug(op, slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// @Summary start sync
+// @Tags admin
+// @Description re-read personal data of all users from the people info service in the background
+// @Produce json
+// @Success 202 {object} dto.SyncRun
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /admin/sync [post]
+func (s *Server) startSyncHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.startSyncHandler"
+
+	run, err := s.service.StartSync(r.Context(), entity.SyncManual)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(run)
+	}
+}
+
+// @Summary get sync status
+// @Tags admin
+// @Description get the status of the latest sync run
+// @Produce json
+// @Success 200 {object} dto.SyncRun
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /admin/sync [get]
+func (s *Server) getSyncHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getSyncHandler"
+
+	run, err := s.service.LastSync(r.Context())
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(run)
+	}
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/policy"
+)
+
+// @Summary set user role
+// @Tags users
+// @Description set user role: employee, manager or admin
+// @Accept json
+// @Produce json
+// @Param user path string true "user id"
+// @Param request body dto.RoleRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /users/{user}/role [put]
+func (s *Server) setUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.setUserRoleHandler"
+
+	var req dto.RoleRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	userID := chi.URLParam(r, "user")
+
+	if err := s.service.SetUserRole(r.Context(), userID, req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary add team
+// @Tags teams
+// @Description add team
+// @Accept json
+// @Produce json
+// @Param request body dto.TeamRequest true "request body"
+// @Success 201 {object} dto.Team
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams [post]
+func (s *Server) addTeamHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.addTeamHandler"
+
+	var req dto.TeamRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	team, err := s.service.AddTeam(r.Context(), req)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(team)
+	}
+}
+
+// @Summary get teams
+// @Tags teams
+// @Description get teams, managers only see the teams they lead
+// @Accept json
+// @Produce json
+// @Success 200 {array} dto.Team
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams [get]
+func (s *Server) getTeamsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getTeamsHandler"
+
+	managerID, err := s.policy.ReadTeam(principal(r))
+	if err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	teams, err := s.service.GetTeams(r.Context(), managerID)
+	if err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(teams)
+	}
+}
+
+// @Summary get team
+// @Tags teams
+// @Description get team with its members
+// @Accept json
+// @Produce json
+// @Param team path string true "team id"
+// @Success 200 {object} dto.Team
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams/{team} [get]
+func (s *Server) getTeamHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.getTeamHandler"
+
+	managerID, err := s.policy.ReadTeam(principal(r))
+	if err != nil {
+		s.denied(w, r, op, err)
+
+		return
+	}
+
+	teamID := chi.URLParam(r, "team")
+
+	team, err := s.service.GetTeam(r.Context(), teamID)
+	if err != nil {
+		s.failed(w, r, op, err)
+
+		return
+	}
+
+	if managerID != 0 && team.ManagerID != managerID {
+		s.denied(w, r, op, policy.ErrForbidden)
+
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(team)
+}
+
+// @Summary update team
+// @Tags teams
+// @Description update team name or manager
+// @Accept json
+// @Produce json
+// @Param team path string true "team id"
+// @Param request body dto.TeamRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams/{team} [patch]
+func (s *Server) updateTeamHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.updateTeamHandler"
+
+	var req dto.TeamRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	teamID := chi.URLParam(r, "team")
+
+	if err := s.service.UpdateTeam(r.Context(), teamID, req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary delete team
+// @Tags teams
+// @Description delete team, its members are kept
+// @Accept json
+// @Produce json
+// @Param team path string true "team id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams/{team} [delete]
+func (s *Server) deleteTeamHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.deleteTeamHandler"
+
+	teamID := chi.URLParam(r, "team")
+
+	if err := s.service.DeleteTeam(r.Context(), teamID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary add team member
+// @Tags teams
+// @Description add user to team
+// @Accept json
+// @Produce json
+// @Param team path string true "team id"
+// @Param request body dto.TeamMemberRequest true "request body"
+// @Success 200
+// @Failure 400 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams/{team}/members [post]
+func (s *Server) addTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.addTeamMemberHandler"
+
+	var req dto.TeamMemberRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		s.badRequest(w, r, op, err, BadRequestError)
+
+		return
+	}
+
+	teamID := chi.URLParam(r, "team")
+
+	if err := s.service.AddTeamMember(r.Context(), teamID, req); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary delete team member
+// @Tags teams
+// @Description remove user from team
+// @Accept json
+// @Produce json
+// @Param team path string true "team id"
+// @Param user path string true "user id"
+// @Success 200
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router       /teams/{team}/members/{user} [delete]
+func (s *Server) deleteTeamMemberHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "server.Server.deleteTeamMemberHandler"
+
+	teamID := chi.URLParam(r, "team")
+	userID := chi.URLParam(r, "user")
+
+	if err := s.service.DeleteTeamMember(r.Context(), teamID, userID); err != nil {
+		s.failed(w, r, op, err)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
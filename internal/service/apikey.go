@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/njslxve/time-tracker-service/internal/auth"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+const apiKeyPrefix = "tt_"
+
+func (s *Service) AddAPIKey(ctx context.Context, req dto.APIKeyRequest) (dto.APIKeyCreatedResponse, error) {
+	const op = "service.Service.AddAPIKey"
+
+	if req.Name == "" {
+		return dto.APIKeyCreatedResponse{}, fmt.Errorf("%s: %w", op, ErrEmptyAPIKeyName)
+	}
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return dto.APIKeyCreatedResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(b)
+
+	id, err := s.db.AddAPIKey(ctx, entity.APIKey{
+		Name:    req.Name,
+		KeyHash: hashToken(key),
+		UserID:  req.UserID,
+		Admin:   req.Admin,
+	})
+	if err != nil {
+		return dto.APIKeyCreatedResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	apiKey, err := s.db.GetAPIKey(ctx, id)
+	if err != nil {
+		return dto.APIKeyCreatedResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dto.APIKeyCreatedResponse{
+		APIKey: apiKeyResponse(apiKey),
+		Key:    key,
+	}, nil
+}
+
+func (s *Service) GetAPIKeys(ctx context.Context) ([]dto.APIKey, error) {
+	const op = "service.Service.GetAPIKeys"
+
+	keys, err := s.db.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keysRes := make([]dto.APIKey, 0)
+
+	for _, key := range keys {
+		keysRes = append(keysRes, apiKeyResponse(key))
+	}
+
+	return keysRes, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, keyID string) error {
+	const op = "service.Service.RevokeAPIKey"
+
+	id, _ := strconv.Atoi(keyID)
+
+	err := s.db.RevokeAPIKey(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%s: %w", op, ErrAPIKeyNotFound)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey resolves a presented key to a principal. The key from
+// ADMIN_API_KEY is accepted without a database lookup so that the first
+// stored keys can be issued.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (auth.Principal, error) {
+	const op = "service.Service.AuthenticateAPIKey"
+
+	if s.cfg.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.cfg.AdminAPIKey)) == 1 {
+		return auth.Principal{
+			Subject: "admin",
+			Method:  auth.MethodAPIKey,
+			Admin:   true,
+		}, nil
+	}
+
+	apiKey, err := s.db.UseAPIKey(ctx, hashToken(key))
+	if errors.Is(err, storage.ErrNotFound) {
+		return auth.Principal{}, fmt.Errorf("%s: %w", op, auth.ErrUnauthenticated)
+	}
+
+	if err != nil {
+		return auth.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return auth.Principal{
+		Subject: "api_key:" + strconv.Itoa(apiKey.ID),
+		UserID:  apiKey.UserID,
+		Method:  auth.MethodAPIKey,
+		Admin:   apiKey.Admin,
+	}, nil
+}
+
+func apiKeyResponse(key entity.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		UserID:     key.UserID,
+		Admin:      key.Admin,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+	"github.com/njslxve/time-tracker-service/internal/transport/api"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+// Kinds of failures. Every domain error is of one kind, which tells the
+// caller how to react to it without knowing the error itself.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrAlreadyExists       = errors.New("already exists")
+	ErrConflict            = errors.New("conflict")
+	ErrValidation          = errors.New("validation failed")
+	ErrForbidden           = errors.New("forbidden")
+	ErrUpstreamBadGateway  = errors.New("upstream returned an invalid response")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrUpstreamTimeout     = errors.New("upstream timed out")
+)
+
+// Error is a domain error: a failure of Kind, with a Code that stays the
+// same when Message is reworded. Validation errors may name the fields that
+// failed.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []dto.FieldError
+}
+
+func newError(kind error, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is the kind of the error, so that
+// errors.Is(err, ErrNotFound) holds for every not found error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Errors standing in for failures of the storage and the people info service
+// that no method turned into an error of its own.
+var (
+	errNotFound            = newError(ErrNotFound, "not_found", "resource not found")
+	errAlreadyExists       = newError(ErrAlreadyExists, "already_exists", "resource already exists")
+	errConflict            = newError(ErrConflict, "conflict", "resource was changed by another request, please try again")
+	errPassportUnknown     = newError(ErrValidation, "passport_unknown", api.ErrNotFound.Error())
+	errPassportRejected    = newError(ErrValidation, "passport_rejected", api.ErrRejected.Error())
+	errUpstreamBadGateway  = newError(ErrUpstreamBadGateway, "upstream_bad_gateway", api.ErrBadGateway.Error())
+	errUpstreamUnavailable = newError(ErrUpstreamUnavailable, "upstream_unavailable", "people info service is unavailable, please try again later")
+	errUpstreamTimeout     = newError(ErrUpstreamTimeout, "upstream_timeout", api.ErrTimeout.Error())
+)
+
+// AsError returns the domain error err stands for, if any. Storage and
+// upstream failures are of their own kind even where a method passed them on
+// as they were.
+func AsError(err error) (*Error, bool) {
+	var (
+		domain  *Error
+		running *RunningTaskError
+		invalid *passport.Error
+	)
+
+	switch {
+	case errors.As(err, &domain):
+		return domain, true
+	case errors.As(err, &running):
+		return newError(ErrConflict, "task_running", running.Error()), true
+	case errors.As(err, &invalid):
+		return &Error{
+			Kind:    ErrValidation,
+			Code:    "invalid_passport",
+			Message: invalid.Error(),
+			Fields:  fieldErrors(invalid),
+		}, true
+	case errors.Is(err, storage.ErrNotFound):
+		return errNotFound, true
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return errAlreadyExists, true
+	case errors.Is(err, storage.ErrConflict):
+		return errConflict, true
+	case errors.Is(err, api.ErrNotFound):
+		return errPassportUnknown, true
+	case errors.Is(err, api.ErrRejected):
+		return errPassportRejected, true
+	case errors.Is(err, api.ErrBadGateway):
+		return errUpstreamBadGateway, true
+	case errors.Is(err, api.ErrUnavailable):
+		return errUpstreamUnavailable, true
+	case errors.Is(err, api.ErrTimeout):
+		return errUpstreamTimeout, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+)
+
+var ErrImportTooLarge = newError(ErrValidation, "import_too_large", "import has too many rows")
+
+// Failures of single rows, reported in the row rather than failing the import.
+var (
+	errImportDuplicate = newError(ErrConflict, "duplicate_passport", "same passport as an earlier row")
+	errImportTimeout   = newError(ErrUpstreamTimeout, "import_timeout", "import ran out of time before the row was looked up")
+)
+
+// ImportUsers validates the passports, looks each of them up in the people
+// info service and, unless dryRun is set, creates the users in batches. Every
+// row gets a result of its own; only a failing storage aborts the import.
+func (s *Service) ImportUsers(ctx context.Context, passports []string, dryRun bool) (dto.ImportReport, error) {
+	const op = "service.Service.ImportUsers"
+
+	if len(passports) > s.cfg.ImportMaxRows {
+		return dto.ImportReport{}, fmt.Errorf("%s: %w", op, ErrImportTooLarge)
+	}
+
+	rows := make([]dto.ImportRow, len(passports))
+	seen := make(map[string]int, len(passports))
+
+	var pending []int
+
+	for i, value := range passports {
+		rows[i] = dto.ImportRow{
+			Row:      i + 1,
+			Passport: value,
+		}
+
+		number, err := passport.Normalize(value)
+		if err != nil {
+			rows[i].Status = entity.ImportInvalid
+			rowError(&rows[i], err)
+
+			continue
+		}
+
+		rows[i].Passport = number
+
+		if row, ok := seen[number]; ok {
+			rows[i].Status = entity.ImportDuplicate
+			rowError(&rows[i], errImportDuplicate)
+			rows[i].Error = fmt.Sprintf("same passport as row %d", row)
+
+			continue
+		}
+
+		seen[number] = i + 1
+		pending = append(pending, i)
+	}
+
+	pending, err := s.skipExisting(ctx, rows, pending)
+	if err != nil {
+		return dto.ImportReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pending = s.enrich(ctx, rows, pending)
+
+	if dryRun {
+		for _, i := range pending {
+			rows[i].Status = entity.ImportValid
+		}
+	} else {
+		for start := 0; start < len(pending); start += s.cfg.ImportBatchSize {
+			batch := pending[start:min(start+s.cfg.ImportBatchSize, len(pending))]
+
+			s.importBatch(ctx, rows, batch)
+		}
+	}
+
+	report := dto.ImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   rows,
+	}
+
+	for _, row := range rows {
+		switch row.Status {
+		case entity.ImportCreated, entity.ImportValid:
+			report.Imported++
+		case entity.ImportDuplicate, entity.ImportExists:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// skipExisting marks rows whose passport already belongs to a user and
+// returns the rest of pending.
+func (s *Service) skipExisting(ctx context.Context, rows []dto.ImportRow, pending []int) ([]int, error) {
+	const op = "service.Service.skipExisting"
+
+	existing := make(map[string]bool)
+
+	for start := 0; start < len(pending); start += s.cfg.ImportBatchSize {
+		batch := pending[start:min(start+s.cfg.ImportBatchSize, len(pending))]
+
+		passports := make([]string, 0, len(batch))
+		for _, i := range batch {
+			passports = append(passports, rows[i].Passport)
+		}
+
+		found, err := s.db.ExistingPassports(ctx, passports)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, number := range found {
+			existing[number] = true
+		}
+	}
+
+	var rest []int
+
+	for _, i := range pending {
+		if existing[rows[i].Passport] {
+			rows[i].Status = entity.ImportExists
+			rowError(&rows[i], ErrUserExists)
+
+			continue
+		}
+
+		rest = append(rest, i)
+	}
+
+	return rest, nil
+}
+
+// enrich looks up the pending rows with at most cfg.ImportConcurrency
+// requests in flight and returns the rows that were found. Rows left when ctx
+// is done are marked as failed.
+func (s *Service) enrich(ctx context.Context, rows []dto.ImportRow, pending []int) []int {
+	const op = "service.Service.enrich"
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, max(s.cfg.ImportConcurrency, 1))
+	)
+
+	for _, i := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				rows[i].Status = entity.ImportFailed
+				rowError(&rows[i], errImportTimeout)
+
+				return
+			}
+
+			userInfo, err := s.cached.Info(ctx, rows[i].Passport)
+			if err != nil {
+				rows[i].Status = entity.ImportFailed
+
+				if !rowError(&rows[i], err) {
+					s.logger.Error(op, slog.String("error", err.Error()))
+				}
+
+				return
+			}
+
+			rows[i].Info = &userInfo
+		}()
+	}
+
+	wg.Wait()
+
+	var found []int
+
+	for _, i := range pending {
+		if rows[i].Status == "" {
+			found = append(found, i)
+		}
+	}
+
+	return found
+}
+
+// importBatch creates the users of batch. If the batch cannot be saved, its
+// rows are marked as failed and the import goes on with the next one.
+func (s *Service) importBatch(ctx context.Context, rows []dto.ImportRow, batch []int) {
+	const op = "service.Service.importBatch"
+
+	users := make([]entity.User, 0, len(batch))
+
+	for _, i := range batch {
+		users = append(users, entity.User{
+			Passport:   rows[i].Passport,
+			Name:       rows[i].Info.Name,
+			Surmame:    rows[i].Info.Surname,
+			Patronymic: rows[i].Info.Patronymic,
+			Adress:     rows[i].Info.Adress,
+		})
+	}
+
+	userIDs, err := s.db.ImportUsers(ctx, users)
+	if err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	for _, i := range batch {
+		if err != nil {
+			rows[i].Status = entity.ImportFailed
+			rowError(&rows[i], err)
+
+			continue
+		}
+
+		rows[i].Status = entity.ImportCreated
+		rows[i].UserID = userIDs[rows[i].Passport]
+	}
+}
+
+// rowError reports err in row the way problems report it: the code and message
+// of the domain error err stands for. Any other error is internal, its details
+// are not shown and rowError returns false.
+func rowError(row *dto.ImportRow, err error) bool {
+	domain, ok := AsError(err)
+	if !ok {
+		row.Code = "internal"
+		row.Error = "row could not be imported"
+
+		return false
+	}
+
+	row.Code = domain.Code
+	row.Error = domain.Message
+	row.Fields = domain.Fields
+
+	return true
+}
+
+func fieldErrors(err error) []dto.FieldError {
+	var invalid *passport.Error
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+
+	fields := make([]dto.FieldError, 0, len(invalid.Fields))
+	for _, field := range invalid.Fields {
+		fields = append(fields, dto.FieldError{Field: field.Field, Message: field.Message})
+	}
+
+	return fields
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+func (s *Service) AddProject(ctx context.Context, req dto.ProjectRequest) (dto.Project, error) {
+	const op = "service.Service.AddProject"
+
+	if req.Name == "" {
+		return dto.Project{}, fmt.Errorf("%s: %w", op, ErrEmptyProjectName)
+	}
+
+	project := entity.Project{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	id, err := s.db.AddProject(ctx, project)
+	if err != nil {
+		return dto.Project{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	project, err = s.db.GetProject(ctx, id)
+	if err != nil {
+		return dto.Project{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return projectResponse(project), nil
+}
+
+func (s *Service) GetProject(ctx context.Context, projectID string) (dto.Project, error) {
+	const op = "service.Service.GetProject"
+
+	id, _ := strconv.Atoi(projectID)
+
+	project, err := s.db.GetProject(ctx, id)
+	if err != nil {
+		return dto.Project{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return projectResponse(project), nil
+}
+
+func (s *Service) GetProjects(ctx context.Context) ([]dto.Project, error) {
+	const op = "service.Service.GetProjects"
+
+	projects, err := s.db.GetProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	projectsRes := make([]dto.Project, 0)
+
+	for _, project := range projects {
+		projectsRes = append(projectsRes, projectResponse(project))
+	}
+
+	return projectsRes, nil
+}
+
+func (s *Service) UpdateProject(ctx context.Context, projectID string, req dto.ProjectRequest) error {
+	const op = "service.Service.UpdateProject"
+
+	id, _ := strconv.Atoi(projectID)
+
+	project, err := s.db.GetProject(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.Name != "" {
+		project.Name = req.Name
+	}
+
+	if req.Description != "" {
+		project.Description = req.Description
+	}
+
+	err = s.db.UpdateProject(ctx, project)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteProject(ctx context.Context, projectID string) error {
+	const op = "service.Service.DeleteProject"
+
+	id, _ := strconv.Atoi(projectID)
+
+	err := s.db.DeleteProject(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) AddTaskDefinition(ctx context.Context, req dto.AddTaskDefinitionRequest) error {
+	const op = "service.Service.AddTaskDefinition"
+
+	if req.TaskID == "" {
+		return fmt.Errorf("%s: %w", op, ErrEmptyTaskID)
+	}
+
+	definition := entity.TaskDefinition{
+		TaskID:      req.TaskID,
+		ProjectID:   req.ProjectID,
+		Title:       req.Title,
+		Description: req.Description,
+	}
+
+	if definition.Title == "" {
+		definition.Title = definition.TaskID
+	}
+
+	err := s.db.AddTaskDefinition(ctx, definition)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetTaskDefinition(ctx context.Context, taskID string) (dto.TaskDefinition, error) {
+	const op = "service.Service.GetTaskDefinition"
+
+	definition, err := s.db.GetTaskDefinition(ctx, taskID)
+	if err != nil {
+		return dto.TaskDefinition{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return taskDefinitionResponse(definition), nil
+}
+
+func (s *Service) GetTaskDefinitions(ctx context.Context, projectID string) ([]dto.TaskDefinition, error) {
+	const op = "service.Service.GetTaskDefinitions"
+
+	id, _ := strconv.Atoi(projectID)
+
+	definitions, err := s.db.GetTaskDefinitions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	definitionsRes := make([]dto.TaskDefinition, 0)
+
+	for _, definition := range definitions {
+		definitionsRes = append(definitionsRes, taskDefinitionResponse(definition))
+	}
+
+	return definitionsRes, nil
+}
+
+func (s *Service) UpdateTaskDefinition(ctx context.Context, taskID string, req dto.UpdateTaskDefinitionRequest) error {
+	const op = "service.Service.UpdateTaskDefinition"
+
+	definition, err := s.db.GetTaskDefinition(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.ProjectID != nil {
+		definition.ProjectID = *req.ProjectID
+	}
+
+	if req.Title != "" {
+		definition.Title = req.Title
+	}
+
+	if req.Description != "" {
+		definition.Description = req.Description
+	}
+
+	err = s.db.UpdateTaskDefinition(ctx, definition)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteTaskDefinition(ctx context.Context, taskID string) error {
+	const op = "service.Service.DeleteTaskDefinition"
+
+	err := s.db.DeleteTaskDefinition(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func projectResponse(project entity.Project) dto.Project {
+	return dto.Project{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		CreatedAt:   project.CreatedAt,
+	}
+}
+
+func taskDefinitionResponse(definition entity.TaskDefinition) dto.TaskDefinition {
+	return dto.TaskDefinition{
+		TaskID:      definition.TaskID,
+		ProjectID:   definition.ProjectID,
+		ProjectName: definition.ProjectName,
+		Title:       definition.Title,
+		Description: definition.Description,
+	}
+}
@@ -1,100 +1,336 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/cursor"
 	"github.com/njslxve/time-tracker-service/internal/model/dto"
 	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
 )
 
 type StrorageInterface interface {
-	AddUser(entity.User) error
-	GetUser(int) (entity.User, error)
-	GetUsers(entity.FilterOptions) ([]entity.User, error)
-	UpdateUser(entity.User) error
-	DeleteUser(int) error
-	AddTask(entity.Task) error
-	GetTask(string, int) (entity.Task, error)
-	GetTasks(int, int) ([]entity.Task, error)
-	UpdateTask(entity.Task) error
-	TokenData(string) (int, entity.TokenData, error)
-	AddToken(string, int, []byte) error
+	AddUser(context.Context, entity.User) (int, error)
+	GetUser(context.Context, int) (entity.User, error)
+	LockUser(context.Context, int) (entity.User, error)
+	GetUsers(context.Context, entity.FilterOptions, entity.Page) ([]entity.User, error)
+	UpdateUser(context.Context, entity.User) error
+	ArchiveUser(context.Context, int, time.Time) error
+	RestoreUser(context.Context, int) error
+	PurgeUser(context.Context, int) error
+	AddTask(context.Context, entity.Task, bool) (entity.Task, error)
+	GetTask(context.Context, string, int) (entity.Task, error)
+	LockTask(context.Context, string, int) (entity.Task, error)
+	GetTasks(context.Context, int, int) ([]entity.Task, error)
+	GetTaskTotals(context.Context, int, int) ([]entity.TaskTotal, error)
+	GetUserReport(context.Context, entity.ReportOptions) ([]entity.ReportRow, error)
+	GetSummaryReport(context.Context, entity.SummaryOptions) ([]entity.UserTotal, int, error)
+	Timesheet(context.Context, entity.TimesheetOptions, func(entity.TimesheetRow) error) error
+	SetCalendarToken(context.Context, int, string) error
+	CalendarToken(context.Context, int) (string, error)
+	AddProject(context.Context, entity.Project) (int, error)
+	GetProject(context.Context, int) (entity.Project, error)
+	GetProjects(context.Context) ([]entity.Project, error)
+	UpdateProject(context.Context, entity.Project) error
+	DeleteProject(context.Context, int) error
+	AddTaskDefinition(context.Context, entity.TaskDefinition) error
+	GetTaskDefinition(context.Context, string) (entity.TaskDefinition, error)
+	GetTaskDefinitions(context.Context, int) ([]entity.TaskDefinition, error)
+	UpdateTaskDefinition(context.Context, entity.TaskDefinition) error
+	DeleteTaskDefinition(context.Context, string) error
+	UpdateTask(context.Context, entity.Task) error
+	AddSession(context.Context, entity.Task) error
+	GetSession(context.Context, string) (entity.Task, error)
+	LockSession(context.Context, string) (entity.Task, error)
+	HasOverlap(context.Context, entity.Task) (bool, error)
+	AddSegment(context.Context, entity.Segment) error
+	GetSegments(context.Context, []string) ([]entity.Segment, error)
+	UpdateSegment(context.Context, entity.Segment) error
+	UserRole(context.Context, int) (string, error)
+	SetUserRole(context.Context, int, string) error
+	AddTeam(context.Context, entity.Team) (int, error)
+	GetTeam(context.Context, int) (entity.Team, error)
+	GetTeams(context.Context, int) ([]entity.Team, error)
+	UpdateTeam(context.Context, entity.Team) error
+	DeleteTeam(context.Context, int) error
+	AddTeamMember(context.Context, int, int) error
+	DeleteTeamMember(context.Context, int, int) error
+	AddAPIKey(context.Context, entity.APIKey) (int, error)
+	UseAPIKey(context.Context, string) (entity.APIKey, error)
+	GetAPIKey(context.Context, int) (entity.APIKey, error)
+	GetAPIKeys(context.Context) ([]entity.APIKey, error)
+	RevokeAPIKey(context.Context, int) error
+	GetAuditLog(context.Context, entity.AuditOptions) ([]entity.AuditEntry, int, error)
+	GetEnrichmentJobs(context.Context, []int) ([]entity.EnrichmentJob, error)
+	StartSyncRun(context.Context, string, time.Duration) (entity.SyncRun, error)
+	GetSyncBatch(context.Context, int, int) ([]entity.User, error)
+	ApplySync(context.Context, int64, int, []entity.FieldChange) (int, error)
+	FinishSyncRun(context.Context, entity.SyncRun) error
+	LastSyncRun(context.Context) (entity.SyncRun, error)
+	ExistingPassports(context.Context, []string) ([]string, error)
+	ImportUsers(context.Context, []entity.User) (map[string]int, error)
+	WithTx(context.Context, entity.TxOptions, func(context.Context) error) error
+	Ping(context.Context) error
+}
+
+var (
+	ErrTaskPaused    = newError(ErrConflict, "task_paused", "task is paused")
+	ErrTaskNotPaused = newError(ErrConflict, "task_not_paused", "task is not paused")
+	ErrTaskNotFound  = newError(ErrNotFound, "task_not_found", "user has no running task with this task_id")
+
+	ErrInvalidInterval = newError(ErrValidation, "invalid_interval", "start time must be before end time and neither may be in the future")
+	ErrTaskOverlap     = newError(ErrConflict, "task_overlap", "session overlaps another session of the user")
+
+	ErrInvalidCalendarToken = newError(ErrForbidden, "invalid_calendar_token", "invalid calendar token")
+
+	ErrEmptyProjectName = newError(ErrValidation, "empty_project_name", "project name must not be empty")
+	ErrEmptyTaskID      = newError(ErrValidation, "empty_task_id", "task_id must not be empty")
+
+	ErrUserNotFound    = newError(ErrNotFound, "user_not_found", "user not found")
+	ErrUserExists      = newError(ErrAlreadyExists, "user_exists", "user with this passport already exists")
+	ErrUserArchived    = newError(ErrConflict, "user_archived", "user is archived")
+	ErrUserNotArchived = newError(ErrConflict, "user_not_archived", "user is not archived")
+	ErrInvalidCursor   = newError(ErrValidation, "invalid_cursor", "page cursor is invalid or does not match the filter")
+
+	ErrInvalidRole        = newError(ErrValidation, "invalid_role", "role must be one of employee, manager, admin")
+	ErrEmptyTeamName      = newError(ErrValidation, "empty_team_name", "team name must not be empty")
+	ErrInvalidTeamManager = newError(ErrValidation, "invalid_team_manager", "team manager must have the manager or admin role")
+
+	ErrEmptyAPIKeyName = newError(ErrValidation, "empty_api_key_name", "api key name must not be empty")
+	ErrAPIKeyNotFound  = newError(ErrNotFound, "api_key_not_found", "api key not found or already revoked")
+)
+
+type RunningTaskError struct {
+	Task dto.TaskResponse
+}
+
+func (e *RunningTaskError) Error() string {
+	return fmt.Sprintf("user already has a running task %s", e.Task.TaskID)
+}
+
+func (e *RunningTaskError) Is(target error) bool {
+	return target == ErrConflict
 }
 
 type APIInterface interface {
-	Info(string) (dto.UserInfoResponse, error)
+	Info(context.Context, string) (dto.UserInfoResponse, error)
+}
+
+// CachedAPIInterface also forgets what it remembered about a passport.
+type CachedAPIInterface interface {
+	APIInterface
+	Evict(context.Context, string) error
 }
 
+const defaultPageSize = 10
+
+// Service looks people up through cached, unless it needs the current data
+// of the people info service, as sync does, and asks api. Work it runs in the
+// background lasts no longer than ctx, the lifetime of the service.
 type Service struct {
-	cfg    *config.Config
-	logger *slog.Logger
-	db     StrorageInterface
-	api    APIInterface
+	ctx     context.Context
+	cfg     *config.Config
+	logger  *slog.Logger
+	db      StrorageInterface
+	api     APIInterface
+	cached  CachedAPIInterface
+	cursors *cursor.Signer
 }
 
-func New(cfg *config.Config, logger *slog.Logger, db StrorageInterface, api APIInterface) *Service {
+func New(ctx context.Context, cfg *config.Config, logger *slog.Logger, db StrorageInterface, api APIInterface, cached CachedAPIInterface) *Service {
 	return &Service{
-		cfg:    cfg,
-		logger: logger,
-		db:     db,
-		api:    api,
+		ctx:     ctx,
+		cfg:     cfg,
+		logger:  logger,
+		db:      db,
+		api:     api,
+		cached:  cached,
+		cursors: cursor.New(cfg.CursorSecret),
 	}
 }
 
-func (s *Service) AddUser(req dto.AddUserRequest) error {
+// Ping checks that the database answers.
+func (s *Service) Ping(ctx context.Context) error {
+	const op = "service.Service.Ping"
+
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// inTx runs fn as one unit of work of the storage with the configured
+// isolation level. fn may be run more than once.
+func (s *Service) inTx(ctx context.Context, fn func(context.Context) error) error {
+	opts := entity.TxOptions{
+		Isolation: s.cfg.DBTxIsolation,
+		Retries:   s.cfg.DBTxRetries,
+	}
+
+	return s.db.WithTx(ctx, opts, fn)
+}
+
+// AddUser creates the user right away. Their name and address are filled in
+// later from the people info service by the enrichment workers.
+func (s *Service) AddUser(ctx context.Context, req dto.AddUserRequest) (dto.AddUserResponse, error) {
 	const op = "service.Service.AddUser"
 
-	userInfo, err := s.api.Info(req.Passport)
+	number, err := passport.Normalize(req.Passport)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return dto.AddUserResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	user := entity.User{
-		Passport:   req.Passport,
-		Name:       userInfo.Name,
-		Surmame:    userInfo.Surname,
-		Patronymic: userInfo.Patronymic,
-		Adress:     userInfo.Adress,
+		Passport: number,
+	}
+
+	userID, err := s.db.AddUser(ctx, user)
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		return dto.AddUserResponse{}, fmt.Errorf("%s: %w", op, ErrUserExists)
 	}
 
-	err = s.db.AddUser(user)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return dto.AddUserResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return dto.AddUserResponse{
+		UserID: userID,
+		Status: entity.UserPendingEnrichment,
+	}, nil
 }
 
-func (s *Service) AddTask(req dto.TaskRequest) error {
+func (s *Service) GetUser(ctx context.Context, userID string) (dto.User, error) {
+	const op = "service.Service.GetUser"
+
+	id, _ := strconv.Atoi(userID)
+
+	user, err := s.db.GetUser(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return dto.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	if err != nil {
+		return dto.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	users, err := s.userResponses(ctx, []entity.User{user})
+	if err != nil {
+		return dto.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users[0], nil
+}
+
+func (s *Service) AddTask(ctx context.Context, req dto.TaskRequest) error {
+	const op = "service.Service.AddTask"
+
+	if err := s.activeUser(ctx, req.UserID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	task := entity.Task{
 		TaskID:    req.TaskID,
 		UserID:    req.UserID,
 		StartTime: time.Now(),
 	}
 
-	return s.db.AddTask(task)
+	stopRunning := s.cfg.RunningTaskPolicy == config.PolicyAutoStop
+
+	running, err := s.db.AddTask(ctx, task, stopRunning)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if running.ID != "" && !stopRunning {
+		return fmt.Errorf("%s: %w", op, &RunningTaskError{
+			Task: dto.TaskResponse{
+				SessionID: running.ID,
+				TaskID:    running.TaskID,
+				UserID:    running.UserID,
+				StartTime: running.StartTime,
+			},
+		})
+	}
+
+	return nil
 }
 
-func (s *Service) EndTask(req dto.TaskRequest) error {
-	const op = "service.Service.EndTask"
+func (s *Service) PauseTask(ctx context.Context, req dto.TaskRequest) error {
+	const op = "service.Service.PauseTask"
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		task, err := s.db.LockTask(ctx, req.TaskID, req.UserID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrTaskNotFound
+		}
+
+		if err != nil {
+			return err
+		}
 
-	task, err := s.db.GetTask(req.TaskID, req.UserID)
+		segments, err := s.db.GetSegments(ctx, []string{task.ID})
+		if err != nil {
+			return err
+		}
+
+		segment, ok := openSegment(segments)
+		if !ok {
+			return ErrTaskPaused
+		}
+
+		segment.EndTime = time.Now()
+
+		return s.db.UpdateSegment(ctx, segment)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	task.EndTime = time.Now()
+	return nil
+}
+
+func (s *Service) ResumeTask(ctx context.Context, req dto.TaskRequest) error {
+	const op = "service.Service.ResumeTask"
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		task, err := s.db.LockTask(ctx, req.TaskID, req.UserID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrTaskNotFound
+		}
 
-	task.Duration = int(task.EndTime.Sub(task.StartTime).Minutes())
+		if err != nil {
+			return err
+		}
 
-	err = s.db.UpdateTask(task)
+		segments, err := s.db.GetSegments(ctx, []string{task.ID})
+		if err != nil {
+			return err
+		}
+
+		if _, ok := openSegment(segments); ok {
+			return ErrTaskNotPaused
+		}
+
+		segment := entity.Segment{
+			TaskUUID:  task.ID,
+			StartTime: time.Now(),
+		}
+
+		return s.db.AddSegment(ctx, segment)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -102,37 +338,189 @@ func (s *Service) EndTask(req dto.TaskRequest) error {
 	return nil
 }
 
-func (s *Service) UpdateUser(userID string, req dto.UpdateUserRequest) error {
-	const op = "service.Service.UpdateUser"
+// EndTask ends the running task with its open segment. The task stays locked
+// until it is saved, so of two concurrent requests only one ends it and the
+// other finds no running task.
+func (s *Service) EndTask(ctx context.Context, req dto.TaskRequest) error {
+	const op = "service.Service.EndTask"
 
-	id, _ := strconv.Atoi(userID)
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		task, err := s.db.LockTask(ctx, req.TaskID, req.UserID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrTaskNotFound
+		}
 
-	user, err := s.db.GetUser(id)
+		if err != nil {
+			return err
+		}
+
+		segments, err := s.db.GetSegments(ctx, []string{task.ID})
+		if err != nil {
+			return err
+		}
+
+		task.EndTime = time.Now()
+
+		for i := range segments {
+			if segments[i].EndTime.IsZero() {
+				segments[i].EndTime = task.EndTime
+			}
+		}
+
+		task.Segments = segments
+
+		return s.db.UpdateTask(ctx, task)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if req.Name != "" {
-		user.Name = req.Name
+	return nil
+}
+
+func (s *Service) AddManualTask(ctx context.Context, req dto.ManualTaskRequest) error {
+	const op = "service.Service.AddManualTask"
+
+	if !req.StartTime.Before(req.EndTime) || req.EndTime.After(time.Now()) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidInterval)
+	}
+
+	if err := s.activeUser(ctx, req.UserID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	task := entity.Task{
+		TaskID:    req.TaskID,
+		UserID:    req.UserID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Segments: []entity.Segment{
+			{StartTime: req.StartTime, EndTime: req.EndTime},
+		},
 	}
 
-	if req.Surname != "" {
-		user.Surmame = req.Surname
+	overlaps, err := s.db.HasOverlap(ctx, task)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if req.Patronymic != "" {
-		user.Patronymic = req.Patronymic
+	if overlaps {
+		return fmt.Errorf("%s: %w", op, ErrTaskOverlap)
 	}
 
-	if req.Passport != "" {
-		user.Passport = req.Passport
+	err = s.db.AddSession(ctx, task)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if req.Adress != "" {
-		user.Adress = req.Adress
+	return nil
+}
+
+// UpdateSession changes the times of a session, locked from reading it to
+// saving it so that concurrent changes, pausing and ending included, cannot
+// overwrite each other.
+func (s *Service) UpdateSession(ctx context.Context, sessionID string, req dto.UpdateTaskRequest) error {
+	const op = "service.Service.UpdateSession"
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		task, err := s.db.LockSession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		if req.StartTime != nil {
+			task.StartTime = *req.StartTime
+		}
+
+		if req.EndTime != nil {
+			task.EndTime = *req.EndTime
+		}
+
+		if task.StartTime.After(time.Now()) ||
+			(!task.EndTime.IsZero() && (!task.StartTime.Before(task.EndTime) || task.EndTime.After(time.Now()))) {
+			return ErrInvalidInterval
+		}
+
+		overlaps, err := s.db.HasOverlap(ctx, task)
+		if err != nil {
+			return err
+		}
+
+		if overlaps {
+			return ErrTaskOverlap
+		}
+
+		segments, err := s.db.GetSegments(ctx, []string{task.ID})
+		if err != nil {
+			return err
+		}
+
+		task.Segments = adjustSegments(segments, task.StartTime, task.EndTime)
+
+		return s.db.UpdateTask(ctx, task)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = s.db.UpdateUser(user)
+	return nil
+}
+
+// UpdateUser applies the changes of req to the user, locked from reading it
+// to saving it so concurrent updates cannot overwrite each other.
+func (s *Service) UpdateUser(ctx context.Context, userID string, req dto.UpdateUserRequest) error {
+	const op = "service.Service.UpdateUser"
+
+	id, _ := strconv.Atoi(userID)
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		user, err := s.db.LockUser(ctx, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			return ErrUserNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if user.DeletedAt != nil {
+			return ErrUserArchived
+		}
+
+		if req.Name != "" {
+			user.Name = req.Name
+			user.Overridden = override(user.Overridden, entity.FieldName)
+		}
+
+		if req.Surname != "" {
+			user.Surmame = req.Surname
+			user.Overridden = override(user.Overridden, entity.FieldSurname)
+		}
+
+		if req.Patronymic != "" {
+			user.Patronymic = req.Patronymic
+			user.Overridden = override(user.Overridden, entity.FieldPatronymic)
+		}
+
+		if req.Passport != "" {
+			user.Passport, err = passport.Normalize(req.Passport)
+			if err != nil {
+				return err
+			}
+		}
+
+		if req.Adress != "" {
+			user.Adress = req.Adress
+			user.Overridden = override(user.Overridden, entity.FieldAdress)
+		}
+
+		err = s.db.UpdateUser(ctx, user)
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return ErrUserExists
+		}
+
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -140,12 +528,16 @@ func (s *Service) UpdateUser(userID string, req dto.UpdateUserRequest) error {
 	return nil
 }
 
-func (s *Service) DeleteUser(userID string) error {
+func (s *Service) DeleteUser(ctx context.Context, userID string) error {
 	const op = "service.Service.DeleteUser"
 
 	id, _ := strconv.Atoi(userID)
 
-	err := s.db.DeleteUser(id)
+	if err := s.activeUser(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err := s.db.ArchiveUser(ctx, id, time.Now())
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -153,26 +545,131 @@ func (s *Service) DeleteUser(userID string) error {
 	return nil
 }
 
-func (s *Service) GetTasks(userID string, interval string) ([]dto.TaskResponse, error) {
+func (s *Service) RestoreUser(ctx context.Context, userID string) error {
+	const op = "service.Service.RestoreUser"
+
+	id, _ := strconv.Atoi(userID)
+
+	if _, err := s.archivedUser(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err := s.db.RestoreUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) PurgeUser(ctx context.Context, userID string) error {
+	const op = "service.Service.PurgeUser"
+
+	id, _ := strconv.Atoi(userID)
+
+	user, err := s.archivedUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.db.PurgeUser(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The user is gone either way; a cached passport left behind expires
+	// with its entry.
+	if err := s.cached.Evict(ctx, user.Passport); err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	return nil
+}
+
+func (s *Service) activeUser(ctx context.Context, userID int) error {
+	user, err := s.db.GetUser(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrUserNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if user.DeletedAt != nil {
+		return ErrUserArchived
+	}
+
+	return nil
+}
+
+func (s *Service) archivedUser(ctx context.Context, userID int) (entity.User, error) {
+	user, err := s.db.GetUser(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return entity.User{}, ErrUserNotFound
+	}
+
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.DeletedAt == nil {
+		return entity.User{}, ErrUserNotArchived
+	}
+
+	return user, nil
+}
+
+func (s *Service) GetTasks(ctx context.Context, userID string, interval string) ([]dto.TaskResponse, error) {
 	const op = "service.Service.GetTasks"
 
 	id, _ := strconv.Atoi(userID)
 	intrval, _ := strconv.Atoi(interval)
 
-	tasks, err := s.db.GetTasks(id, intrval)
+	tasks, err := s.db.GetTasks(ctx, id, intrval)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ids := make([]string, 0, len(tasks))
+
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+
+	segments, err := s.db.GetSegments(ctx, ids)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	segmentsByTask := make(map[string][]entity.Segment)
+
+	for _, segment := range segments {
+		segmentsByTask[segment.TaskUUID] = append(segmentsByTask[segment.TaskUUID], segment)
+	}
+
 	tasksRes := make([]dto.TaskResponse, 0)
 
 	for _, task := range tasks {
 		t := dto.TaskResponse{
-			TaskID:    task.TaskID,
-			UserID:    task.UserID,
-			StartTime: task.StartTime,
-			EndTime:   task.EndTime,
-			Duration:  convertDuration(task.Duration),
+			SessionID:   task.ID,
+			TaskID:      task.TaskID,
+			Title:       task.Title,
+			ProjectID:   task.ProjectID,
+			ProjectName: task.ProjectName,
+			UserID:      task.UserID,
+			StartTime:   task.StartTime,
+			EndTime:     task.EndTime,
+			Duration:    convertDuration(task.Duration),
+			Segments:    make([]dto.SegmentResponse, 0),
+		}
+
+		for _, segment := range segmentsByTask[task.ID] {
+			t.Segments = append(t.Segments, dto.SegmentResponse{
+				StartTime: segment.StartTime,
+				EndTime:   segment.EndTime,
+				Duration:  convertDuration(segmentsDuration([]entity.Segment{segment})),
+			})
 		}
 
 		tasksRes = append(tasksRes, t)
@@ -181,33 +678,385 @@ func (s *Service) GetTasks(userID string, interval string) ([]dto.TaskResponse,
 	return tasksRes, nil
 }
 
-func (s *Service) GetUsers(filterOpts entity.FilterOptions, paginationOpts entity.PaginationOptions) (dto.GetUsersResponse, error) {
+func (s *Service) GetTaskTotals(ctx context.Context, userID string, interval string) ([]dto.TaskTotalResponse, error) {
+	const op = "service.Service.GetTaskTotals"
+
+	id, _ := strconv.Atoi(userID)
+	intrval, _ := strconv.Atoi(interval)
+
+	totals, err := s.db.GetTaskTotals(ctx, id, intrval)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	totalsRes := make([]dto.TaskTotalResponse, 0)
+
+	for _, total := range totals {
+		t := dto.TaskTotalResponse{
+			TaskID:      total.TaskID,
+			Title:       total.Title,
+			ProjectName: total.ProjectName,
+			UserID:      total.UserID,
+			Sessions:    total.Sessions,
+			FirstStart:  total.FirstStart,
+			LastEnd:     total.LastEnd,
+			Duration:    convertDuration(total.Duration),
+		}
+
+		totalsRes = append(totalsRes, t)
+	}
+
+	return totalsRes, nil
+}
+
+func (s *Service) GetUserReport(ctx context.Context, opts entity.ReportOptions) (dto.UserReportResponse, error) {
+	const op = "service.Service.GetUserReport"
+
+	rows, err := s.db.GetUserReport(ctx, opts)
+	if err != nil {
+		return dto.UserReportResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := dto.UserReportResponse{
+		UserID:  opts.UserID,
+		From:    opts.From,
+		To:      opts.To,
+		GroupBy: opts.GroupBy,
+		Groups:  make([]dto.ReportGroup, 0),
+	}
+
+	for _, row := range rows {
+		report.Minutes += row.Duration
+
+		report.Groups = append(report.Groups, dto.ReportGroup{
+			Key:         row.Key,
+			Title:       row.Title,
+			ProjectName: row.ProjectName,
+			Minutes:     row.Duration,
+			Duration:    convertDuration(row.Duration),
+		})
+	}
+
+	report.Duration = convertDuration(report.Minutes)
+
+	return report, nil
+}
+
+func (s *Service) GetSummaryReport(ctx context.Context, opts entity.SummaryOptions) (dto.SummaryReportResponse, error) {
+	const op = "service.Service.GetSummaryReport"
+
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	totals, count, err := s.db.GetSummaryReport(ctx, opts)
+	if err != nil {
+		return dto.SummaryReportResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := dto.SummaryReportResponse{
+		From:   opts.From,
+		To:     opts.To,
+		Total:  count,
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+		Users:  make([]dto.UserTotal, 0),
+	}
+
+	for _, total := range totals {
+		report.Users = append(report.Users, dto.UserTotal{
+			UserID:     total.UserID,
+			Surname:    total.Surname,
+			Name:       total.Name,
+			Patronymic: total.Patronymic,
+			Minutes:    total.Duration,
+			Duration:   convertDuration(total.Duration),
+		})
+	}
+
+	return report, nil
+}
+
+func (s *Service) GetAuditLog(ctx context.Context, opts entity.AuditOptions) (dto.AuditLogResponse, error) {
+	const op = "service.Service.GetAuditLog"
+
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	entries, count, err := s.db.GetAuditLog(ctx, opts)
+	if err != nil {
+		return dto.AuditLogResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log := dto.AuditLogResponse{
+		From:    opts.From,
+		To:      opts.To,
+		Total:   count,
+		Limit:   opts.Limit,
+		Offset:  opts.Offset,
+		Entries: make([]dto.AuditEntry, 0),
+	}
+
+	for _, entry := range entries {
+		log.Entries = append(log.Entries, dto.AuditEntry{
+			ID:          entry.ID,
+			OccurredAt:  entry.OccurredAt,
+			Actor:       entry.Actor,
+			ActorUserID: entry.ActorUserID,
+			RequestID:   entry.RequestID,
+			Entity:      entry.Entity,
+			EntityID:    entry.EntityID,
+			Operation:   entry.Operation,
+			Before:      entry.Before,
+			After:       entry.After,
+		})
+	}
+
+	return log, nil
+}
+
+func (s *Service) Timesheet(ctx context.Context, opts entity.TimesheetOptions, fn func(dto.TimesheetRow) error) error {
+	const op = "service.Service.Timesheet"
+
+	err := s.db.Timesheet(ctx, opts, func(row entity.TimesheetRow) error {
+		return fn(dto.TimesheetRow{
+			SessionID:   row.SessionID,
+			UserID:      row.UserID,
+			Surname:     row.Surname,
+			Name:        row.Name,
+			Patronymic:  row.Patronymic,
+			TaskID:      row.TaskID,
+			Title:       row.Title,
+			ProjectName: row.ProjectName,
+			StartTime:   row.StartTime,
+			EndTime:     row.EndTime,
+			Minutes:     row.Duration,
+			Duration:    convertDuration(row.Duration),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) RotateCalendarToken(ctx context.Context, userID string) (dto.CalendarTokenResponse, error) {
+	const op = "service.Service.RotateCalendarToken"
+
+	id, _ := strconv.Atoi(userID)
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return dto.CalendarTokenResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token := hex.EncodeToString(b)
+
+	err := s.db.SetCalendarToken(ctx, id, hashToken(token))
+	if err != nil {
+		return dto.CalendarTokenResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dto.CalendarTokenResponse{
+		Token: token,
+		URL:   fmt.Sprintf("%s/users/%d/calendar.ics?token=%s", s.cfg.PublicURL, id, token),
+	}, nil
+}
+
+func (s *Service) CheckCalendarToken(ctx context.Context, userID string, token string) error {
+	const op = "service.Service.CheckCalendarToken"
+
+	id, _ := strconv.Atoi(userID)
+
+	tokenHash, err := s.db.CalendarToken(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCalendarToken)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tokenHash == "" || subtle.ConstantTimeCompare([]byte(tokenHash), []byte(hashToken(token))) != 1 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidCalendarToken)
+	}
+
+	return nil
+}
+
+func (s *Service) Calendar(ctx context.Context, userID string, interval string, fn func(dto.TimesheetRow) error) error {
+	const op = "service.Service.Calendar"
+
+	id, _ := strconv.Atoi(userID)
+	intrval, _ := strconv.Atoi(interval)
+
+	opts := entity.TimesheetOptions{
+		UserID:      id,
+		To:          time.Now(),
+		IncludeOpen: true,
+	}
+
+	if intrval != 0 {
+		now := time.Now()
+		opts.From = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -intrval)
+	}
+
+	err := s.Timesheet(ctx, opts, fn)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetUsers returns a page of users, by default in user_id order or by
+// relevance when searching. Pages are addressed by the cursors of the previous
+// response, which only work with the filter and order they were issued for.
+func (s *Service) GetUsers(ctx context.Context, filterOpts entity.FilterOptions, paginationOpts entity.PaginationOptions) (dto.GetUsersResponse, error) {
 	const op = "service.Service.GetUsers"
 
-	usersData, err := s.db.GetUsers(filterOpts)
+	if paginationOpts.Next != "" && paginationOpts.Prev != "" {
+		return dto.GetUsersResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+	}
+
+	page := entity.Page{
+		Sort:  paginationOpts.Sort,
+		Order: paginationOpts.Order,
+		Limit: paginationOpts.Limit,
+	}
+
+	if page.Sort == "" {
+		page.Sort = entity.SortUserID
+
+		if filterOpts.Query != "" {
+			page.Sort = entity.SortRelevance
+		}
+	}
+
+	if page.Order == "" {
+		page.Order = entity.SortAsc
+
+		if page.Sort == entity.SortRelevance {
+			page.Order = entity.SortDesc
+		}
+	}
+
+	if token := paginationOpts.Next + paginationOpts.Prev; token != "" {
+		c, err := s.cursors.Decode(token)
+		if err != nil || c.Filter != filterOpts || c.Sort != page.Sort || c.Order != page.Order {
+			return dto.GetUsersResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCursor)
+		}
+
+		page.Key = c.Key
+
+		if c.Backward {
+			page.BeforeID = c.UserID
+		} else {
+			page.AfterID = c.UserID
+		}
+
+		if page.Limit <= 0 {
+			page.Limit = c.Limit
+		}
+	}
+
+	if page.Limit <= 0 {
+		page.Limit = defaultPageSize
+	}
+
+	// One user more than asked for tells whether there is another page.
+	query := page
+	query.Limit++
+
+	users, err := s.db.GetUsers(ctx, filterOpts, query)
 	if err != nil {
 		return dto.GetUsersResponse{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var oldLimit int
+	backward := page.BeforeID > 0
 
-	if paginationOpts.Next != "" {
-		old, tokenData, err := s.db.TokenData(paginationOpts.Next)
-		if err != nil {
-			return dto.GetUsersResponse{}, fmt.Errorf("%s: %w", op, err)
+	more := len(users) > page.Limit
+	if more {
+		if backward {
+			users = users[1:]
+		} else {
+			users = users[:page.Limit]
 		}
+	}
 
-		oldLimit = old
+	usersRes, err := s.userResponses(ctx, users)
+	if err != nil {
+		return dto.GetUsersResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		if !s.validateToken(paginationOpts.Next, tokenData, filterOpts) {
-			paginationOpts.Next = ""
-		}
+	res := dto.GetUsersResponse{Users: usersRes}
+
+	if len(users) == 0 {
+		return res, nil
 	}
 
-	users, nextToken := s.paginate(oldLimit, usersData, paginationOpts, filterOpts)
+	if more || backward {
+		last := users[len(users)-1]
+
+		res.Next = s.cursors.Encode(cursor.Cursor{
+			Filter: filterOpts,
+			Sort:   page.Sort,
+			Order:  page.Order,
+			Key:    last.SortKey,
+			UserID: last.UserID,
+			Limit:  page.Limit,
+		})
+	}
+
+	if more && backward || page.AfterID > 0 {
+		res.Prev = s.cursors.Encode(cursor.Cursor{
+			Filter:   filterOpts,
+			Sort:     page.Sort,
+			Order:    page.Order,
+			Key:      users[0].SortKey,
+			UserID:   users[0].UserID,
+			Backward: true,
+			Limit:    page.Limit,
+		})
+	}
+
+	return res, nil
+}
 
+// userResponses renders users together with their latest enrichment job.
+func (s *Service) userResponses(ctx context.Context, users []entity.User) ([]dto.User, error) {
 	usersRes := make([]dto.User, 0)
 
+	if len(users) == 0 {
+		return usersRes, nil
+	}
+
+	userIDs := make([]int, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.UserID)
+	}
+
+	jobs, err := s.db.GetEnrichmentJobs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsByUser := make(map[int]entity.EnrichmentJob, len(jobs))
+	for _, job := range jobs {
+		jobsByUser[job.UserID] = job
+	}
+
 	for _, user := range users {
 		u := dto.User{
 			UserID:     user.UserID,
@@ -216,68 +1065,97 @@ func (s *Service) GetUsers(filterOpts entity.FilterOptions, paginationOpts entit
 			Patronymic: user.Patronymic,
 			Passport:   user.Passport,
 			Adress:     user.Adress,
+			Status:     user.Status,
+			Overridden: user.Overridden,
+			DeletedAt:  user.DeletedAt,
+		}
+
+		if job, ok := jobsByUser[user.UserID]; ok {
+			u.Enrichment = &dto.EnrichmentJob{
+				Status:    job.Status,
+				Attempts:  job.Attempts,
+				LastError: job.LastError,
+				UpdatedAt: job.UpdatedAt,
+			}
+
+			if job.Status == entity.JobQueued {
+				u.Enrichment.NextAttemptAt = &job.RunAt
+			}
 		}
 
 		usersRes = append(usersRes, u)
 	}
 
-	return dto.GetUsersResponse{Users: usersRes, Next: nextToken}, nil
+	return usersRes, nil
 }
 
-func convertDuration(duration int) string {
-	return fmt.Sprintf("%dh%dm", duration/60, duration%60)
+// override marks field as set by hand, so that sync keeps its value.
+func override(fields []string, field string) []string {
+	if slices.Contains(fields, field) {
+		return fields
+	}
+
+	return append(fields, field)
 }
 
-func (s *Service) validateToken(token string, tokenData entity.TokenData, filterOpts entity.FilterOptions) bool {
-	if token == "" {
-		return true
-	}
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
 
-	if !tokenData.IsAlive {
-		return false
-	}
+	return hex.EncodeToString(sum[:])
+}
 
-	dataOpts := entity.FilterOptions{}
+func convertDuration(duration int) string {
+	return fmt.Sprintf("%dh%dm", duration/60, duration%60)
+}
 
-	if err := json.Unmarshal(tokenData.Params, &dataOpts); err != nil {
-		return false
+func openSegment(segments []entity.Segment) (entity.Segment, bool) {
+	for _, segment := range segments {
+		if segment.EndTime.IsZero() {
+			return segment, true
+		}
 	}
 
-	return dataOpts == filterOpts
+	return entity.Segment{}, false
 }
 
-func (s *Service) paginate(oldLimit int, users []entity.User, paginationOpts entity.PaginationOptions, filterOpts entity.FilterOptions) ([]entity.User, string) {
-	if paginationOpts.Limit == 0 {
-		paginationOpts.Limit = 10
-	}
+func adjustSegments(segments []entity.Segment, start time.Time, end time.Time) []entity.Segment {
+	adjusted := make([]entity.Segment, 0, len(segments))
 
-	switch paginationOpts.Next {
-	case "":
-		if len(users) <= paginationOpts.Limit {
-			return users, ""
-		} else {
-			return users[:paginationOpts.Limit], s.newToken(paginationOpts.Limit, filterOpts)
+	for _, segment := range segments {
+		if !segment.EndTime.IsZero() && !segment.EndTime.After(start) {
+			continue
 		}
-	default:
-		if len(users[oldLimit:]) <= paginationOpts.Limit {
-			return users[oldLimit:], ""
-		} else {
-			return users[oldLimit : oldLimit+paginationOpts.Limit], s.newToken(oldLimit+paginationOpts.Limit, filterOpts)
+
+		if !end.IsZero() && !segment.StartTime.Before(end) {
+			continue
 		}
+
+		adjusted = append(adjusted, segment)
+	}
+
+	if len(adjusted) == 0 {
+		return []entity.Segment{{StartTime: start, EndTime: end}}
 	}
+
+	adjusted[0].StartTime = start
+
+	if !end.IsZero() {
+		adjusted[len(adjusted)-1].EndTime = end
+	}
+
+	return adjusted
 }
 
-func (s *Service) newToken(limit int, filterOpts entity.FilterOptions) string {
-	params, _ := json.Marshal(filterOpts)
+func segmentsDuration(segments []entity.Segment) int {
+	var total time.Duration
 
-	str := uuid.NewString()
-	new := strings.ReplaceAll(str, "-", "")
-	token := new[:15]
+	for _, segment := range segments {
+		if segment.EndTime.IsZero() {
+			continue
+		}
 
-	err := s.db.AddToken(string(token), limit, params)
-	if err != nil {
-		return ""
+		total += segment.EndTime.Sub(segment.StartTime)
 	}
 
-	return token
+	return int(total.Minutes())
 }
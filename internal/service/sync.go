@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/audit"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/transport/api"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+// SyncActor is recorded in the audit log for scheduled sync runs.
+const SyncActor = "sync"
+
+var (
+	ErrSyncRunning = newError(ErrConflict, "sync_running", "synchronization is already running")
+	ErrNoSyncRun   = newError(ErrNotFound, "no_sync_run", "synchronization has not run yet")
+)
+
+// ScheduleSync starts a sync run every cfg.SyncInterval until ctx is done.
+func (s *Service) ScheduleSync(ctx context.Context) {
+	const op = "service.Service.ScheduleSync"
+
+	if s.cfg.SyncInterval <= 0 {
+		return
+	}
+
+	ctx = audit.WithActor(ctx, audit.Actor{Subject: SyncActor})
+
+	ticker := time.NewTicker(s.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.StartSync(ctx, entity.SyncScheduled); err != nil {
+			s.logger.Info(op, slog.String("error", err.Error()))
+		}
+	}
+}
+
+// StartSync starts a sync run in the background unless one is in progress.
+// The run outlives ctx but keeps its audit actor; it is stopped after
+// cfg.SyncTimeout or when the service shuts down.
+func (s *Service) StartSync(ctx context.Context, trigger string) (dto.SyncRun, error) {
+	const op = "service.Service.StartSync"
+
+	actor := audit.ActorFrom(ctx)
+
+	// A run started at the same time gets past the check of StartSyncRun and
+	// is stopped by the index allowing a single running run.
+	run, err := s.db.StartSyncRun(ctx, trigger, s.cfg.SyncTimeout)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrAlreadyExists) {
+		return dto.SyncRun{}, fmt.Errorf("%s: %w", op, ErrSyncRunning)
+	}
+
+	if err != nil {
+		return dto.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(audit.WithActor(s.ctx, actor), s.cfg.SyncTimeout)
+
+	go func() {
+		defer cancel()
+
+		s.sync(ctx, run)
+	}()
+
+	return syncRunResponse(run), nil
+}
+
+func (s *Service) LastSync(ctx context.Context) (dto.SyncRun, error) {
+	const op = "service.Service.LastSync"
+
+	run, err := s.db.LastSyncRun(ctx)
+	if errors.Is(err, storage.ErrNotFound) {
+		return dto.SyncRun{}, fmt.Errorf("%s: %w", op, ErrNoSyncRun)
+	}
+
+	if err != nil {
+		return dto.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return syncRunResponse(run), nil
+}
+
+// sync walks all enriched users in batches and applies what the people info
+// service reports differently. Lookup failures of single users are counted
+// and skipped; an unavailable upstream ends the run.
+func (s *Service) sync(ctx context.Context, run entity.SyncRun) {
+	const op = "service.Service.sync"
+
+	run.Status = entity.SyncDone
+
+	afterUserID := 0
+
+batches:
+	for {
+		users, err := s.db.GetSyncBatch(ctx, afterUserID, s.cfg.SyncBatchSize)
+		if err != nil {
+			run.Status = entity.SyncFailed
+			run.LastError = err.Error()
+
+			break
+		}
+
+		if len(users) == 0 {
+			break
+		}
+
+		for _, user := range users {
+			if err := ctx.Err(); err != nil {
+				run.Status = entity.SyncFailed
+				run.LastError = err.Error()
+
+				break batches
+			}
+
+			afterUserID = user.UserID
+			run.Checked++
+
+			userInfo, err := s.api.Info(ctx, user.Passport)
+			if errors.Is(err, api.ErrUnavailable) {
+				run.Status = entity.SyncFailed
+				run.LastError = err.Error()
+
+				break batches
+			}
+
+			if err != nil {
+				run.Failed++
+				run.LastError = err.Error()
+
+				continue
+			}
+
+			changes := diffUser(user, userInfo)
+			if len(changes) == 0 {
+				continue
+			}
+
+			applied, err := s.db.ApplySync(ctx, run.ID, user.UserID, changes)
+			if err != nil {
+				run.Failed++
+				run.LastError = err.Error()
+
+				continue
+			}
+
+			if applied > 0 {
+				run.Updated++
+			}
+		}
+	}
+
+	// A run stopped by its context is still recorded as finished.
+	if err := s.db.FinishSyncRun(context.WithoutCancel(ctx), run); err != nil {
+		s.logger.Error(op, slog.String("error", err.Error()))
+	}
+
+	s.logger.Info("sync finished",
+		slog.Int64("run", run.ID),
+		slog.String("status", run.Status),
+		slog.Int("checked", run.Checked),
+		slog.Int("updated", run.Updated),
+		slog.Int("failed", run.Failed),
+	)
+}
+
+// diffUser lists the fields of user that differ from userInfo and were not
+// overridden by hand. Blank answers never erase stored data.
+func diffUser(user entity.User, userInfo dto.UserInfoResponse) []entity.FieldChange {
+	fields := []entity.FieldChange{
+		{Field: entity.FieldName, Old: user.Name, New: userInfo.Name},
+		{Field: entity.FieldSurname, Old: user.Surmame, New: userInfo.Surname},
+		{Field: entity.FieldPatronymic, Old: user.Patronymic, New: userInfo.Patronymic},
+		{Field: entity.FieldAdress, Old: user.Adress, New: userInfo.Adress},
+	}
+
+	var changes []entity.FieldChange
+
+	for _, field := range fields {
+		if field.New == "" || field.Old == field.New || slices.Contains(user.Overridden, field.Field) {
+			continue
+		}
+
+		changes = append(changes, field)
+	}
+
+	return changes
+}
+
+func syncRunResponse(run entity.SyncRun) dto.SyncRun {
+	return dto.SyncRun{
+		ID:         run.ID,
+		Trigger:    run.Trigger,
+		Status:     run.Status,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		Checked:    run.Checked,
+		Updated:    run.Updated,
+		Failed:     run.Failed,
+		LastError:  run.LastError,
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+	"github.com/njslxve/time-tracker-service/internal/transport/storage"
+)
+
+func (s *Service) SetUserRole(ctx context.Context, userID string, req dto.RoleRequest) error {
+	const op = "service.Service.SetUserRole"
+
+	switch req.Role {
+	case entity.RoleEmployee, entity.RoleManager, entity.RoleAdmin:
+	default:
+		return fmt.Errorf("%s: %w", op, ErrInvalidRole)
+	}
+
+	id, _ := strconv.Atoi(userID)
+
+	err := s.db.SetUserRole(ctx, id, req.Role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) AddTeam(ctx context.Context, req dto.TeamRequest) (dto.Team, error) {
+	const op = "service.Service.AddTeam"
+
+	if req.Name == "" {
+		return dto.Team{}, fmt.Errorf("%s: %w", op, ErrEmptyTeamName)
+	}
+
+	if err := s.checkTeamManager(ctx, req.ManagerID); err != nil {
+		return dto.Team{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := s.db.AddTeam(ctx, entity.Team{
+		Name:      req.Name,
+		ManagerID: req.ManagerID,
+	})
+	if err != nil {
+		return dto.Team{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	team, err := s.db.GetTeam(ctx, id)
+	if err != nil {
+		return dto.Team{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return teamResponse(team), nil
+}
+
+func (s *Service) GetTeam(ctx context.Context, teamID string) (dto.Team, error) {
+	const op = "service.Service.GetTeam"
+
+	id, _ := strconv.Atoi(teamID)
+
+	team, err := s.db.GetTeam(ctx, id)
+	if err != nil {
+		return dto.Team{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return teamResponse(team), nil
+}
+
+func (s *Service) GetTeams(ctx context.Context, managerID int) ([]dto.Team, error) {
+	const op = "service.Service.GetTeams"
+
+	teams, err := s.db.GetTeams(ctx, managerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	teamsRes := make([]dto.Team, 0)
+
+	for _, team := range teams {
+		teamsRes = append(teamsRes, teamResponse(team))
+	}
+
+	return teamsRes, nil
+}
+
+func (s *Service) UpdateTeam(ctx context.Context, teamID string, req dto.TeamRequest) error {
+	const op = "service.Service.UpdateTeam"
+
+	id, _ := strconv.Atoi(teamID)
+
+	team, err := s.db.GetTeam(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.Name != "" {
+		team.Name = req.Name
+	}
+
+	if req.ManagerID != 0 {
+		if err := s.checkTeamManager(ctx, req.ManagerID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		team.ManagerID = req.ManagerID
+	}
+
+	err = s.db.UpdateTeam(ctx, team)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteTeam(ctx context.Context, teamID string) error {
+	const op = "service.Service.DeleteTeam"
+
+	id, _ := strconv.Atoi(teamID)
+
+	err := s.db.DeleteTeam(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) AddTeamMember(ctx context.Context, teamID string, req dto.TeamMemberRequest) error {
+	const op = "service.Service.AddTeamMember"
+
+	id, _ := strconv.Atoi(teamID)
+
+	err := s.db.AddTeamMember(ctx, id, req.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteTeamMember(ctx context.Context, teamID string, userID string) error {
+	const op = "service.Service.DeleteTeamMember"
+
+	id, _ := strconv.Atoi(teamID)
+	uid, _ := strconv.Atoi(userID)
+
+	err := s.db.DeleteTeamMember(ctx, id, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Service) checkTeamManager(ctx context.Context, userID int) error {
+	if userID == 0 {
+		return nil
+	}
+
+	role, err := s.db.UserRole(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInvalidTeamManager
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if role != entity.RoleManager && role != entity.RoleAdmin {
+		return ErrInvalidTeamManager
+	}
+
+	return nil
+}
+
+func teamResponse(team entity.Team) dto.Team {
+	members := team.Members
+	if members == nil {
+		members = make([]int, 0)
+	}
+
+	return dto.Team{
+		ID:        team.ID,
+		Name:      team.Name,
+		ManagerID: team.ManagerID,
+		Members:   members,
+		CreatedAt: team.CreatedAt,
+	}
+}
@@ -1,58 +1,171 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
-	"strings"
+	"net/url"
+	"time"
 
 	"github.com/njslxve/time-tracker-service/internal/config"
 	"github.com/njslxve/time-tracker-service/internal/model/dto"
+	"github.com/njslxve/time-tracker-service/internal/model/passport"
+)
+
+var (
+	ErrNotFound    = errors.New("passport is unknown to the people info service")
+	ErrRejected    = errors.New("people info service rejected the passport")
+	ErrBadGateway  = errors.New("people info service returned an invalid response")
+	ErrUnavailable = errors.New("people info service is unavailable")
+	ErrTimeout     = errors.New("people info service timed out")
 )
 
 type API struct {
-	logger *slog.Logger
-	cfg    *config.Config
+	logger  *slog.Logger
+	cfg     *config.Config
+	client  *http.Client
+	breaker *breaker
 }
 
 func New(logger *slog.Logger, cfg *config.Config) *API {
 	return &API{
 		logger: logger,
 		cfg:    cfg,
+		client: &http.Client{
+			Timeout: cfg.InfoAPITimeout,
+		},
+		breaker: newBreaker(cfg.InfoAPIBreakerThreshold, cfg.InfoAPIBreakerCooldown),
 	}
 }
 
-func (a *API) Info(passport string) (dto.UserInfoResponse, error) {
+// Info looks up a passport, retrying network errors and 5xx responses with
+// exponential backoff. Failures are reported as one of the package errors or
+// as the error of ctx once it is done.
+func (a *API) Info(ctx context.Context, value string) (dto.UserInfoResponse, error) {
 	const op = "api.API.Info"
 
-	data := strings.Split(passport, " ")
+	p, err := passport.Parse(value)
+	if err != nil {
+		return dto.UserInfoResponse{}, fmt.Errorf("%s: %w: %w", op, ErrRejected, err)
+	}
+
+	endpoint, err := url.Parse(a.cfg.InfoAPIURL)
+	if err != nil {
+		return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-	serie, number := data[0], data[1]
+	query := endpoint.Query()
+	query.Set("passportSerie", p.Series)
+	query.Set("passportNumber", p.Number)
+	endpoint.RawQuery = query.Encode()
 
-	url := fmt.Sprintf("%s?passportSerie=%s&passportNumber=%s", a.cfg.InfoAPIURL, serie, number)
+	for attempt := 0; attempt <= a.cfg.InfoAPIRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, ctx.Err())
+			case <-time.After(a.backoff(attempt)):
+			}
+		}
+
+		if !a.breaker.allow() {
+			return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, ErrUnavailable)
+		}
+
+		var (
+			userInfo dto.UserInfoResponse
+			retry    bool
+		)
+
+		userInfo, retry, err = a.get(ctx, endpoint.String())
+		if ctx.Err() != nil {
+			a.breaker.release()
+
+			return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, ctx.Err())
+		}
+
+		if !retry {
+			a.breaker.success()
+
+			if err != nil {
+				return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, err)
+			}
+
+			return userInfo, nil
+		}
+
+		a.breaker.failure()
 
-	resp, err := http.Get(url)
-	if err != nil {
 		a.logger.Debug("external api error",
 			slog.String("description", op),
+			slog.Int("attempt", attempt+1),
 			slog.String("error", err.Error()),
 		)
+	}
 
-		return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, err)
+	return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, err)
+}
+
+// get performs a single request. retry reports whether the failure is
+// transient and counts against the upstream's health.
+func (a *API) get(ctx context.Context, endpoint string) (dto.UserInfoResponse, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return dto.UserInfoResponse{}, false, err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return dto.UserInfoResponse{}, true, fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+
+		return dto.UserInfoResponse{}, true, fmt.Errorf("%w: %w", ErrBadGateway, err)
 	}
 	defer resp.Body.Close()
 
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return dto.UserInfoResponse{}, false, ErrNotFound
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return dto.UserInfoResponse{}, true, fmt.Errorf("%w: status %d", ErrBadGateway, resp.StatusCode)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return dto.UserInfoResponse{}, false, fmt.Errorf("%w: status %d", ErrRejected, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return dto.UserInfoResponse{}, false, fmt.Errorf("%w: status %d", ErrBadGateway, resp.StatusCode)
+	}
+
 	var userInfo dto.UserInfoResponse
 
 	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
 		a.logger.Debug("could not decode response",
-			slog.String("description", op),
 			slog.String("error", err.Error()),
 		)
 
-		return dto.UserInfoResponse{}, fmt.Errorf("%s: %w", op, err)
+		return dto.UserInfoResponse{}, false, fmt.Errorf("%w: %w", ErrBadGateway, err)
+	}
+
+	return userInfo, false, nil
+}
+
+// backoff doubles the base delay with every attempt up to the configured
+// maximum and picks a random duration below it, so that clients retrying
+// at the same time spread out.
+func (a *API) backoff(attempt int) time.Duration {
+	delay := a.cfg.InfoAPIBackoff << (attempt - 1)
+	if delay <= 0 || delay > a.cfg.InfoAPIMaxBackoff {
+		delay = a.cfg.InfoAPIMaxBackoff
+	}
+
+	if delay <= 0 {
+		return 0
 	}
 
-	return userInfo, nil
+	return time.Duration(rand.Int63n(int64(delay)))
 }
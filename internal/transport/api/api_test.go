@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/njslxve/time-tracker-service/internal/config"
+	"github.com/njslxve/time-tracker-service/internal/model/dto"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		backoff    time.Duration
+		maxBackoff time.Duration
+		attempt    int
+		want       time.Duration
+	}{
+		{name: "first retry", backoff: 100 * time.Millisecond, maxBackoff: time.Second, attempt: 1, want: 100 * time.Millisecond},
+		{name: "doubled", backoff: 100 * time.Millisecond, maxBackoff: time.Second, attempt: 3, want: 400 * time.Millisecond},
+		{name: "capped", backoff: 100 * time.Millisecond, maxBackoff: time.Second, attempt: 5, want: time.Second},
+		{name: "overflow capped", backoff: 100 * time.Millisecond, maxBackoff: time.Second, attempt: 64, want: time.Second},
+		{name: "no maximum", backoff: 100 * time.Millisecond, attempt: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &API{cfg: &config.Config{InfoAPIBackoff: tt.backoff, InfoAPIMaxBackoff: tt.maxBackoff}}
+
+			for i := 0; i < 100; i++ {
+				got := a.backoff(tt.attempt)
+
+				if got < 0 || got > tt.want || (tt.want > 0 && got == tt.want) {
+					t.Fatalf("backoff(%d) = %v, want below %v", tt.attempt, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestInfoRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		retries   int
+		threshold int
+		calls     int
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "success",
+			statuses:  []int{http.StatusOK},
+			retries:   3,
+			calls:     1,
+			wantCalls: 1,
+		},
+		{
+			name:      "retried until success",
+			statuses:  []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			retries:   3,
+			calls:     1,
+			wantCalls: 3,
+		},
+		{
+			name:      "retries exhausted",
+			statuses:  []int{http.StatusInternalServerError},
+			retries:   2,
+			calls:     1,
+			wantErr:   ErrBadGateway,
+			wantCalls: 3,
+		},
+		{
+			name:      "not found not retried",
+			statuses:  []int{http.StatusNotFound},
+			retries:   3,
+			calls:     1,
+			wantErr:   ErrNotFound,
+			wantCalls: 1,
+		},
+		{
+			name:      "rejected not retried",
+			statuses:  []int{http.StatusBadRequest},
+			retries:   3,
+			calls:     1,
+			wantErr:   ErrRejected,
+			wantCalls: 1,
+		},
+		{
+			name:      "breaker opens",
+			statuses:  []int{http.StatusInternalServerError},
+			retries:   3,
+			threshold: 2,
+			calls:     2,
+			wantErr:   ErrUnavailable,
+			wantCalls: 2,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	info := dto.UserInfoResponse{Name: "Иван", Surname: "Иванов", Patronymic: "Иванович", Adress: "Москва"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int64
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				status := tt.statuses[min(n, len(tt.statuses))-1]
+
+				w.WriteHeader(status)
+
+				if status == http.StatusOK {
+					w.Write([]byte(`{"name":"Иван","surname":"Иванов","patronymic":"Иванович","adress":"Москва"}`))
+				}
+			}))
+			defer srv.Close()
+
+			a := New(logger, &config.Config{
+				InfoAPIURL:              srv.URL,
+				InfoAPITimeout:          time.Second,
+				InfoAPIRetries:          tt.retries,
+				InfoAPIBackoff:          time.Millisecond,
+				InfoAPIMaxBackoff:       time.Millisecond,
+				InfoAPIBreakerThreshold: tt.threshold,
+				InfoAPIBreakerCooldown:  time.Minute,
+			})
+
+			var (
+				got dto.UserInfoResponse
+				err error
+			)
+
+			for i := 0; i < tt.calls; i++ {
+				got, err = a.Info(context.Background(), "1234 567890")
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Info error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Info error = %v", err)
+			} else if got != info {
+				t.Errorf("Info = %+v, want %+v", got, info)
+			}
+
+			if got := int(requests.Load()); got != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
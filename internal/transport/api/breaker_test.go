@@ -0,0 +1,89 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+type breakerStep int
+
+const (
+	allowed breakerStep = iota
+	rejected
+	succeed
+	fail
+	release
+	cool
+)
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []breakerStep
+	}{
+		{
+			name:      "closed below threshold",
+			threshold: 3,
+			steps:     []breakerStep{fail, fail, allowed},
+		},
+		{
+			name:      "success resets failures",
+			threshold: 3,
+			steps:     []breakerStep{fail, fail, succeed, fail, fail, allowed},
+		},
+		{
+			name:      "opens at threshold",
+			threshold: 3,
+			steps:     []breakerStep{fail, fail, fail, rejected},
+		},
+		{
+			name:      "single probe after cooldown",
+			threshold: 2,
+			steps:     []breakerStep{fail, fail, cool, allowed, rejected},
+		},
+		{
+			name:      "probe success closes",
+			threshold: 2,
+			steps:     []breakerStep{fail, fail, cool, allowed, succeed, allowed, allowed},
+		},
+		{
+			name:      "probe failure reopens",
+			threshold: 2,
+			steps:     []breakerStep{fail, fail, cool, allowed, fail, rejected, cool, allowed},
+		},
+		{
+			name:      "released probe lets the next through",
+			threshold: 2,
+			steps:     []breakerStep{fail, fail, cool, allowed, release, allowed, rejected},
+		},
+		{
+			name:      "disabled",
+			threshold: 0,
+			steps:     []breakerStep{fail, fail, fail, allowed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(tt.threshold, time.Minute)
+
+			for i, step := range tt.steps {
+				switch step {
+				case allowed, rejected:
+					if got, want := b.allow(), step == allowed; got != want {
+						t.Fatalf("step %d: allow() = %v, want %v", i, got, want)
+					}
+				case succeed:
+					b.success()
+				case fail:
+					b.failure()
+				case release:
+					b.release()
+				case cool:
+					b.openedAt = b.openedAt.Add(-b.cooldown)
+				}
+			}
+		})
+	}
+}
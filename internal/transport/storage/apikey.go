@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+var apiKeyColumns = []string{"id", "name", "key_hash", "COALESCE(user_id, 0)", "is_admin", "created_at", "last_used_at", "revoked_at"}
+
+func (s *Storage) AddAPIKey(ctx context.Context, key entity.APIKey) (int, error) {
+	const op = "transport.storage.AddAPIKey"
+
+	querry := qb.Insert("api_keys").
+		Columns("name", "key_hash", "user_id", "is_admin").
+		Values(key.Name, key.KeyHash, nullableID(key.UserID), key.Admin)
+
+	return s.create(ctx, op, "api_keys", "id", querry)
+}
+
+// apiKeyUseInterval is how often the last use of a key is recorded, so that
+// requests do not each write to the key.
+const apiKeyUseInterval = time.Minute
+
+// UseAPIKey looks up an active key by its hash and records the lookup as
+// its last use, unless one was recorded within apiKeyUseInterval.
+func (s *Storage) UseAPIKey(ctx context.Context, keyHash string) (entity.APIKey, error) {
+	const op = "transport.storage.UseAPIKey"
+
+	querry := qb.Select(apiKeyColumns...).
+		From("api_keys").
+		Where(sq.And{
+			sq.Eq{"key_hash": keyHash},
+			sq.Eq{"revoked_at": nil},
+		})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var key entity.APIKey
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&key.ID, &key.Name, &key.KeyHash, &key.UserID, &key.Admin, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if key.LastUsedAt != nil && time.Since(*key.LastUsedAt) < apiKeyUseInterval {
+		return key, nil
+	}
+
+	// Requests made with the key at the same time record a single use.
+	useQuerry := qb.Update("api_keys").
+		Set("last_used_at", sq.Expr("now()")).
+		Where(sq.And{
+			sq.Eq{"id": key.ID},
+			sq.Or{
+				sq.Eq{"last_used_at": nil},
+				sq.Lt{"last_used_at": time.Now().Add(-apiKeyUseInterval)},
+			},
+		})
+
+	if err := s.exec(ctx, op, useQuerry); err != nil && !errors.Is(err, ErrNotFound) {
+		return entity.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (s *Storage) GetAPIKey(ctx context.Context, id int) (entity.APIKey, error) {
+	const op = "transport.storage.GetAPIKey"
+
+	querry := qb.Select(apiKeyColumns...).
+		From("api_keys").
+		Where(sq.Eq{"id": id})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var key entity.APIKey
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&key.ID, &key.Name, &key.KeyHash, &key.UserID, &key.Admin, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+func (s *Storage) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	const op = "transport.storage.GetAPIKeys"
+
+	querry := qb.Select(apiKeyColumns...).
+		From("api_keys").
+		OrderBy("id")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var keys []entity.APIKey
+
+	for rows.Next() {
+		var key entity.APIKey
+
+		err = rows.Scan(&key.ID, &key.Name, &key.KeyHash, &key.UserID, &key.Admin, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int) error {
+	const op = "transport.storage.RevokeAPIKey"
+
+	querry := qb.Update("api_keys").
+		Set("revoked_at", sq.Expr("now()")).
+		Where(sq.And{
+			sq.Eq{"id": id},
+			sq.Eq{"revoked_at": nil},
+		})
+
+	return s.audited(ctx, op, change{
+		entity:    "api_keys",
+		entityID:  strconv.Itoa(id),
+		operation: auditRevoke,
+		where:     sq.Eq{"id": id},
+	}, querry)
+}
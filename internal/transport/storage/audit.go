@@ -0,0 +1,356 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/njslxve/time-tracker-service/internal/audit"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+const (
+	auditCreate       = "create"
+	auditUpdate       = "update"
+	auditDelete       = "delete"
+	auditArchive      = "archive"
+	auditRestore      = "restore"
+	auditPurge        = "purge"
+	auditStart        = "start"
+	auditStop         = "stop"
+	auditAddSegment   = "add_segment"
+	auditCloseSegment = "close_segment"
+	auditRotateToken  = "rotate_calendar_token"
+	auditRevoke       = "revoke"
+	auditEnrich       = "enrich"
+	auditSync         = "sync"
+)
+
+// snapshotColumns overrides how a row is rendered into the audit log. Tasks
+// carry their segments so pauses and resumes show up in the diff.
+var snapshotColumns = map[string]string{
+	"tasks": "to_jsonb(t) || jsonb_build_object('segments', (SELECT COALESCE(jsonb_agg(to_jsonb(ts) - 'task_uuid' ORDER BY ts.start_time), '[]') FROM task_segments ts WHERE ts.task_uuid = t.id))",
+}
+
+// personalFields are the keys of a user snapshot that are removed from the
+// audit log when the user is purged.
+var personalFields = []string{"passport", "first_name", "last_name", "patronymic", "adress"}
+
+// Secrets are never copied into the audit log, only their hashes live in
+// these columns but even those do not belong there.
+const defaultSnapshotColumn = "to_jsonb(t) - ARRAY['calendar_token', 'key_hash']"
+
+func snapshotColumn(table string) string {
+	if column, ok := snapshotColumns[table]; ok {
+		return column
+	}
+
+	return defaultSnapshotColumn
+}
+
+type change struct {
+	entity    string
+	entityID  string
+	operation string
+	where     sq.Sqlizer
+}
+
+// snapshot returns the row of table matched by where as JSON, or nil when
+// there is none.
+func (s *Storage) snapshot(ctx context.Context, tx pgx.Tx, op string, table string, where sq.Sqlizer) ([]byte, error) {
+	querry := qb.Select(snapshotColumn(table)).
+		From(table + " t").
+		Where(where).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var data []byte
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&data)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+func (s *Storage) audit(ctx context.Context, tx pgx.Tx, op string, c change, before []byte, after []byte) error {
+	actor := audit.ActorFrom(ctx)
+
+	querry := qb.Insert("audit_log").
+		Columns("actor", "actor_user_id", "request_id", "entity", "entity_id", "operation", "before_state", "after_state").
+		Values(actor.Subject, nullableID(actor.UserID), actor.RequestID, c.entity, c.entityID, c.operation, before, after)
+
+	return s.execTx(ctx, tx, op, querry)
+}
+
+// record snapshots the row selected by c before and after mutate and writes
+// the difference to the audit log within tx.
+func (s *Storage) record(ctx context.Context, tx pgx.Tx, op string, c change, mutate func() error) error {
+	before, err := s.snapshot(ctx, tx, op, c.entity, c.where)
+	if err != nil {
+		return err
+	}
+
+	if err := mutate(); err != nil {
+		return err
+	}
+
+	after, err := s.snapshot(ctx, tx, op, c.entity, c.where)
+	if err != nil {
+		return err
+	}
+
+	return s.audit(ctx, tx, op, c, before, after)
+}
+
+// audited runs querry in a transaction of its own and records the change it
+// makes. Like exec, it fails with ErrNotFound when nothing was affected.
+func (s *Storage) audited(ctx context.Context, op string, c change, querry sq.Sqlizer) error {
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.record(ctx, tx, op, c, func() error {
+		return s.execTxAffecting(ctx, tx, op, querry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// create runs an INSERT returning the key column in a transaction of its own
+// and records the new row.
+func (s *Storage) create(ctx context.Context, op string, table string, key string, querry sq.InsertBuilder) (int, error) {
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := s.createTx(ctx, tx, op, table, key, querry)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) createTx(ctx context.Context, tx pgx.Tx, op string, table string, key string, querry sq.InsertBuilder) (int, error) {
+	sql, args, err := querry.Suffix("RETURNING " + key).ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id int
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&id)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c := change{
+		entity:    table,
+		entityID:  strconv.Itoa(id),
+		operation: auditCreate,
+		where:     sq.Eq{key: id},
+	}
+
+	after, err := s.snapshot(ctx, tx, op, table, c.where)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.audit(ctx, tx, op, c, nil, after); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) execTxAffecting(ctx context.Context, tx pgx.Tx, op string, querry sq.Sqlizer) error {
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetAuditLog(ctx context.Context, opts entity.AuditOptions) ([]entity.AuditEntry, int, error) {
+	const op = "transport.storage.GetAuditLog"
+
+	querry := qb.Select("id", "occurred_at", "actor", "COALESCE(actor_user_id, 0)", "COALESCE(request_id, '')", "entity", "entity_id", "operation", "before_state", "after_state").
+		Column("count(*) OVER ()").
+		From("audit_log").
+		Where(sq.And{
+			sq.GtOrEq{"occurred_at": opts.From},
+			sq.Lt{"occurred_at": opts.To},
+		}).
+		OrderBy("occurred_at DESC", "id DESC").
+		Limit(uint64(opts.Limit)).
+		Offset(uint64(opts.Offset))
+
+	if opts.Entity != "" {
+		querry = querry.Where(sq.Eq{"entity": opts.Entity})
+	}
+
+	if opts.EntityID != "" {
+		querry = querry.Where(sq.Eq{"entity_id": opts.EntityID})
+	}
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var (
+		entries []entity.AuditEntry
+		total   int
+	)
+
+	for rows.Next() {
+		var entry entity.AuditEntry
+
+		err = rows.Scan(&entry.ID, &entry.OccurredAt, &entry.Actor, &entry.ActorUserID, &entry.RequestID, &entry.Entity, &entry.EntityID, &entry.Operation, &entry.Before, &entry.After, &total)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, total, nil
+}
+
+func userChange(userID int, operation string) change {
+	return change{
+		entity:    "users",
+		entityID:  strconv.Itoa(userID),
+		operation: operation,
+		where:     sq.Eq{"user_id": userID},
+	}
+}
+
+func taskChange(id string, operation string) change {
+	return change{
+		entity:    "tasks",
+		entityID:  id,
+		operation: operation,
+		where:     sq.Eq{"id": id},
+	}
+}
+
+// redactUser removes the personal data of userID from every audit entry of
+// the user. The audit log is append-only; the setting, local to tx, is the
+// only way its trigger lets state be rewritten.
+func (s *Storage) redactUser(ctx context.Context, tx pgx.Tx, op string, userID int) error {
+	allowQuerry := qb.Select("set_config('audit_log.redact', 'on', true)")
+
+	if err := s.execTx(ctx, tx, op, allowQuerry); err != nil {
+		return err
+	}
+
+	querry := qb.Update("audit_log").
+		Set("before_state", sq.Expr("before_state - ?::text[]", personalFields)).
+		Set("after_state", sq.Expr("after_state - ?::text[]", personalFields)).
+		Where(sq.Eq{
+			"entity":    "users",
+			"entity_id": strconv.Itoa(userID),
+		})
+
+	return s.execTx(ctx, tx, op, querry)
+}
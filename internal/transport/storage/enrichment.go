@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// ClaimEnrichmentJob takes the next due job, or one whose worker has held it
+// longer than lease, and marks it running. Concurrent workers skip each
+// other's rows instead of waiting on them. ErrNotFound means the queue is
+// empty.
+func (s *Storage) ClaimEnrichmentJob(ctx context.Context, lease time.Duration) (entity.EnrichmentJob, error) {
+	const op = "transport.storage.ClaimEnrichmentJob"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return entity.EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	querry := qb.Select("j.id", "j.user_id", "u.passport", "j.attempts", "j.created_at").
+		From("enrichment_jobs j").
+		Join("users u ON u.user_id = j.user_id").
+		Where(sq.Or{
+			sq.And{
+				sq.Eq{"j.status": entity.JobQueued},
+				sq.Expr("j.run_at <= now()"),
+			},
+			sq.And{
+				sq.Eq{"j.status": entity.JobRunning},
+				sq.Lt{"j.locked_at": time.Now().Add(-lease)},
+			},
+		}).
+		OrderBy("j.run_at").
+		Limit(1).
+		Suffix("FOR UPDATE OF j SKIP LOCKED")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var job entity.EnrichmentJob
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&job.ID, &job.UserID, &job.Passport, &job.Attempts, &job.CreatedAt)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Status = entity.JobRunning
+	job.Attempts++
+
+	updateQuerry := qb.Update("enrichment_jobs").
+		SetMap(map[string]interface{}{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"locked_at":  sq.Expr("now()"),
+			"updated_at": sq.Expr("now()"),
+		}).
+		Where(sq.Eq{"id": job.ID})
+
+	if err := s.execTx(ctx, tx, op, updateQuerry); err != nil {
+		return entity.EnrichmentJob{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return entity.EnrichmentJob{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return job, nil
+}
+
+// CompleteEnrichmentJob stores the personal data found for the job's user and
+// closes the job. Fields overridden by hand while the job was queued are left
+// as they are.
+func (s *Storage) CompleteEnrichmentJob(ctx context.Context, job entity.EnrichmentJob, user entity.User) error {
+	const op = "transport.storage.CompleteEnrichmentJob"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuerry := qb.Select("overridden_fields").
+		From("users").
+		Where(sq.Eq{"user_id": job.UserID}).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := lockQuerry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var overridden []string
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&overridden)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	values := map[string]interface{}{
+		entity.FieldName:       user.Name,
+		entity.FieldSurname:    user.Surmame,
+		entity.FieldPatronymic: user.Patronymic,
+		entity.FieldAdress:     user.Adress,
+		"status":               entity.UserEnriched,
+	}
+
+	for _, field := range overridden {
+		delete(values, field)
+	}
+
+	querry := qb.Update("users").
+		SetMap(values).
+		Where(sq.Eq{"user_id": job.UserID})
+
+	err = s.record(ctx, tx, op, userChange(job.UserID, auditEnrich), func() error {
+		return s.execTx(ctx, tx, op, querry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.execTx(ctx, tx, op, finishJobQuerry(job.ID, entity.JobDone, "")); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RetryEnrichmentJob puts the job back in the queue to run again at runAt.
+func (s *Storage) RetryEnrichmentJob(ctx context.Context, job entity.EnrichmentJob, lastError string, runAt time.Time) error {
+	const op = "transport.storage.RetryEnrichmentJob"
+
+	querry := qb.Update("enrichment_jobs").
+		SetMap(map[string]interface{}{
+			"status":     entity.JobQueued,
+			"last_error": lastError,
+			"run_at":     runAt,
+			"locked_at":  nil,
+			"updated_at": sq.Expr("now()"),
+		}).
+		Where(sq.Eq{"id": job.ID})
+
+	return s.exec(ctx, op, querry)
+}
+
+// FailEnrichmentJob gives up on the job and marks its user as not enriched.
+func (s *Storage) FailEnrichmentJob(ctx context.Context, job entity.EnrichmentJob, lastError string) error {
+	const op = "transport.storage.FailEnrichmentJob"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	querry := qb.Update("users").
+		Set("status", entity.UserEnrichmentFailed).
+		Where(sq.Eq{"user_id": job.UserID})
+
+	err = s.record(ctx, tx, op, userChange(job.UserID, auditEnrich), func() error {
+		return s.execTx(ctx, tx, op, querry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.execTx(ctx, tx, op, finishJobQuerry(job.ID, entity.JobFailed, lastError)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetEnrichmentJobs returns the latest enrichment job of each given user.
+func (s *Storage) GetEnrichmentJobs(ctx context.Context, userIDs []int) ([]entity.EnrichmentJob, error) {
+	const op = "transport.storage.GetEnrichmentJobs"
+
+	querry := qb.Select("DISTINCT ON (user_id) id", "user_id", "status", "attempts", "last_error", "run_at", "created_at", "updated_at").
+		From("enrichment_jobs").
+		Where(sq.Eq{"user_id": userIDs}).
+		OrderBy("user_id", "id DESC")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var jobs []entity.EnrichmentJob
+
+	for rows.Next() {
+		var job entity.EnrichmentJob
+
+		err = rows.Scan(&job.ID, &job.UserID, &job.Status, &job.Attempts, &job.LastError, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+func finishJobQuerry(id int64, status string, lastError string) sq.UpdateBuilder {
+	return qb.Update("enrichment_jobs").
+		SetMap(map[string]interface{}{
+			"status":     status,
+			"last_error": lastError,
+			"locked_at":  nil,
+			"updated_at": sq.Expr("now()"),
+		}).
+		Where(sq.Eq{"id": id})
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Storage errors say what went wrong in terms of the data rather than the
+// driver. The error of the driver stays in the chain behind them.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrConflict      = errors.New("conflict")
+)
+
+const (
+	codeUniqueViolation     = "23505"
+	codeForeignKeyViolation = "23503"
+	codeExclusionViolation  = "23P01"
+	codeLockNotAvailable    = "55P03"
+)
+
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case codeUniqueViolation:
+		return fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+	case codeForeignKeyViolation, codeExclusionViolation, codeLockNotAvailable,
+		codeSerializationFailure, codeDeadlockDetected:
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	default:
+		return err
+	}
+}
+
+// translating runs statements on q and translates the errors they end with,
+// so no storage method has to do it on its own.
+type translating struct {
+	q querier
+}
+
+func (t translating) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := t.q.Begin(ctx)
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	return translatingTx{Tx: tx}, nil
+}
+
+func (t translating) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := t.q.Exec(ctx, sql, args...)
+
+	return tag, translate(err)
+}
+
+func (t translating) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := t.q.Query(ctx, sql, args...)
+
+	return rows, translate(err)
+}
+
+func (t translating) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return translatingRow{row: t.q.QueryRow(ctx, sql, args...)}
+}
+
+func (t translating) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	n, err := t.q.CopyFrom(ctx, tableName, columnNames, rowSrc)
+
+	return n, translate(err)
+}
+
+type translatingRow struct {
+	row pgx.Row
+}
+
+func (r translatingRow) Scan(dest ...any) error {
+	return translate(r.row.Scan(dest...))
+}
+
+// translatingTx is a transaction begun on a translating querier. Its
+// statements, savepoints and commit are translated as well.
+type translatingTx struct {
+	pgx.Tx
+}
+
+func (tx translatingTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return translating{q: tx.Tx}.Begin(ctx)
+}
+
+func (tx translatingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return translating{q: tx.Tx}.Exec(ctx, sql, args...)
+}
+
+func (tx translatingTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return translating{q: tx.Tx}.Query(ctx, sql, args...)
+}
+
+func (tx translatingTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return translating{q: tx.Tx}.QueryRow(ctx, sql, args...)
+}
+
+func (tx translatingTx) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return translating{q: tx.Tx}.CopyFrom(ctx, tableName, columnNames, rowSrc)
+}
+
+func (tx translatingTx) Commit(ctx context.Context) error {
+	return translate(tx.Tx.Commit(ctx))
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/njslxve/time-tracker-service/internal/audit"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// ExistingPassports returns those of passports that already belong to a user,
+// archived ones included.
+func (s *Storage) ExistingPassports(ctx context.Context, passports []string) ([]string, error) {
+	const op = "transport.storage.ExistingPassports"
+
+	querry := qb.Select("passport").
+		From("users").
+		Where(sq.Eq{"passport": passports})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var existing []string
+
+	for rows.Next() {
+		var passport string
+
+		if err := rows.Scan(&passport); err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		existing = append(existing, passport)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return existing, nil
+}
+
+// ImportUsers copies already enriched users in one transaction and records
+// each of them in the audit log. It returns the new user ids by passport.
+func (s *Storage) ImportUsers(ctx context.Context, users []entity.User) (map[string]int, error) {
+	const op = "transport.storage.ImportUsers"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	passports := make([]string, 0, len(users))
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"users"},
+		[]string{"id", "passport", "first_name", "last_name", "patronymic", "adress", "status"},
+		pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+			user := users[i]
+			passports = append(passports, user.Passport)
+
+			return []any{uuid.NewString(), user.Passport, user.Name, user.Surmame, user.Patronymic, user.Adress, entity.UserEnriched}, nil
+		}),
+	)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.Int("rows", len(users)),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	actor := audit.ActorFrom(ctx)
+
+	auditQuerry := qb.Insert("audit_log").
+		Columns("actor", "actor_user_id", "request_id", "entity", "entity_id", "operation", "after_state").
+		Select(sq.Select().
+			Column("?::text", actor.Subject).
+			Column("?::int", nullableID(actor.UserID)).
+			Column("?::text", actor.RequestID).
+			Column("'users'").
+			Column("t.user_id::text").
+			Column("?::text", auditCreate).
+			Column(snapshotColumn("users")).
+			From("users t").
+			Where(sq.Eq{"t.passport": passports}))
+
+	if err := s.execTx(ctx, tx, op, auditQuerry); err != nil {
+		return nil, err
+	}
+
+	querry := qb.Select("user_id", "passport").
+		From("users").
+		Where(sq.Eq{"passport": passports})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userIDs := make(map[string]int, len(users))
+
+	for rows.Next() {
+		var (
+			userID   int
+			passport string
+		)
+
+		if err := rows.Scan(&userID, &passport); err != nil {
+			rows.Close()
+
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		userIDs[passport] = userID
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// GetInfoCache returns the unexpired cache entry for passport or
+// ErrNotFound.
+func (s *Storage) GetInfoCache(ctx context.Context, passport string) (entity.InfoCacheEntry, error) {
+	const op = "transport.storage.GetInfoCache"
+
+	querry := qb.Select("passport", "first_name", "last_name", "patronymic", "adress", "not_found", "expires_at").
+		From("info_cache").
+		Where(sq.And{
+			sq.Eq{"passport": passport},
+			sq.Expr("expires_at > now()"),
+		})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.InfoCacheEntry{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var entry entity.InfoCacheEntry
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&entry.Passport, &entry.Name, &entry.Surname, &entry.Patronymic, &entry.Adress, &entry.NotFound, &entry.ExpiresAt)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.InfoCacheEntry{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+// SetInfoCache stores entry, replacing any previous one for the passport.
+func (s *Storage) SetInfoCache(ctx context.Context, entry entity.InfoCacheEntry) error {
+	const op = "transport.storage.SetInfoCache"
+
+	querry := qb.Insert("info_cache").
+		Columns("passport", "first_name", "last_name", "patronymic", "adress", "not_found", "expires_at").
+		Values(entry.Passport, entry.Name, entry.Surname, entry.Patronymic, entry.Adress, entry.NotFound, entry.ExpiresAt).
+		Suffix("ON CONFLICT (passport) DO UPDATE SET first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name, patronymic = EXCLUDED.patronymic, adress = EXCLUDED.adress, not_found = EXCLUDED.not_found, expires_at = EXCLUDED.expires_at")
+
+	return s.exec(ctx, op, querry)
+}
+
+func (s *Storage) DeleteInfoCache(ctx context.Context, passport string) error {
+	const op = "transport.storage.DeleteInfoCache"
+
+	querry := qb.Delete("info_cache").
+		Where(sq.Eq{"passport": passport})
+
+	if err := s.exec(ctx, op, querry); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteExpiredInfoCache(ctx context.Context) error {
+	const op = "transport.storage.DeleteExpiredInfoCache"
+
+	querry := qb.Delete("info_cache").
+		Where(sq.Expr("expires_at <= now()"))
+
+	if err := s.exec(ctx, op, querry); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+func (s *Storage) AddProject(ctx context.Context, project entity.Project) (int, error) {
+	const op = "transport.storage.AddProject"
+
+	querry := qb.Insert("projects").
+		Columns("name", "description").
+		Values(project.Name, project.Description)
+
+	return s.create(ctx, op, "projects", "id", querry)
+}
+
+func (s *Storage) GetProject(ctx context.Context, id int) (entity.Project, error) {
+	const op = "transport.storage.GetProject"
+
+	querry := qb.Select("id", "name", "description", "created_at").
+		From("projects").
+		Where(sq.Eq{"id": id})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Project{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var project entity.Project
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&project.ID, &project.Name, &project.Description, &project.CreatedAt)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Project{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return project, nil
+}
+
+func (s *Storage) GetProjects(ctx context.Context) ([]entity.Project, error) {
+	const op = "transport.storage.GetProjects"
+
+	querry := qb.Select("id", "name", "description", "created_at").
+		From("projects").
+		OrderBy("name")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var projects []entity.Project
+
+	for rows.Next() {
+		var project entity.Project
+
+		err = rows.Scan(&project.ID, &project.Name, &project.Description, &project.CreatedAt)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
+
+func (s *Storage) UpdateProject(ctx context.Context, project entity.Project) error {
+	const op = "transport.storage.UpdateProject"
+
+	querry := qb.Update("projects").
+		SetMap(map[string]interface{}{
+			"name":        project.Name,
+			"description": project.Description,
+		}).
+		Where(sq.Eq{"id": project.ID})
+
+	return s.audited(ctx, op, change{
+		entity:    "projects",
+		entityID:  strconv.Itoa(project.ID),
+		operation: auditUpdate,
+		where:     sq.Eq{"id": project.ID},
+	}, querry)
+}
+
+func (s *Storage) DeleteProject(ctx context.Context, id int) error {
+	const op = "transport.storage.DeleteProject"
+
+	querry := qb.Delete("projects").
+		Where(sq.Eq{"id": id})
+
+	return s.audited(ctx, op, change{
+		entity:    "projects",
+		entityID:  strconv.Itoa(id),
+		operation: auditDelete,
+		where:     sq.Eq{"id": id},
+	}, querry)
+}
+
+func (s *Storage) AddTaskDefinition(ctx context.Context, definition entity.TaskDefinition) error {
+	const op = "transport.storage.AddTaskDefinition"
+
+	querry := qb.Insert("task_definitions").
+		Columns("task_id", "project_id", "title", "description").
+		Values(definition.TaskID, nullableID(definition.ProjectID), definition.Title, definition.Description)
+
+	return s.audited(ctx, op, change{
+		entity:    "task_definitions",
+		entityID:  definition.TaskID,
+		operation: auditCreate,
+		where:     sq.Eq{"task_id": definition.TaskID},
+	}, querry)
+}
+
+func (s *Storage) GetTaskDefinition(ctx context.Context, taskID string) (entity.TaskDefinition, error) {
+	const op = "transport.storage.GetTaskDefinition"
+
+	querry := taskDefinitionsQuerry().
+		Where(sq.Eq{"d.task_id": taskID})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.TaskDefinition{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var definition entity.TaskDefinition
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).
+		Scan(&definition.TaskID, &definition.ProjectID, &definition.ProjectName, &definition.Title, &definition.Description)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.TaskDefinition{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return definition, nil
+}
+
+func (s *Storage) GetTaskDefinitions(ctx context.Context, projectID int) ([]entity.TaskDefinition, error) {
+	const op = "transport.storage.GetTaskDefinitions"
+
+	querry := taskDefinitionsQuerry().
+		OrderBy("d.task_id")
+
+	if projectID != 0 {
+		querry = querry.Where(sq.Eq{"d.project_id": projectID})
+	}
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var definitions []entity.TaskDefinition
+
+	for rows.Next() {
+		var definition entity.TaskDefinition
+
+		err = rows.Scan(&definition.TaskID, &definition.ProjectID, &definition.ProjectName, &definition.Title, &definition.Description)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, nil
+}
+
+func (s *Storage) UpdateTaskDefinition(ctx context.Context, definition entity.TaskDefinition) error {
+	const op = "transport.storage.UpdateTaskDefinition"
+
+	querry := qb.Update("task_definitions").
+		SetMap(map[string]interface{}{
+			"project_id":  nullableID(definition.ProjectID),
+			"title":       definition.Title,
+			"description": definition.Description,
+		}).
+		Where(sq.Eq{"task_id": definition.TaskID})
+
+	return s.audited(ctx, op, change{
+		entity:    "task_definitions",
+		entityID:  definition.TaskID,
+		operation: auditUpdate,
+		where:     sq.Eq{"task_id": definition.TaskID},
+	}, querry)
+}
+
+func (s *Storage) DeleteTaskDefinition(ctx context.Context, taskID string) error {
+	const op = "transport.storage.DeleteTaskDefinition"
+
+	querry := qb.Delete("task_definitions").
+		Where(sq.Eq{"task_id": taskID})
+
+	return s.audited(ctx, op, change{
+		entity:    "task_definitions",
+		entityID:  taskID,
+		operation: auditDelete,
+		where:     sq.Eq{"task_id": taskID},
+	}, querry)
+}
+
+func (s *Storage) ensureTaskDefinition(ctx context.Context, tx pgx.Tx, taskID string) error {
+	const op = "transport.storage.ensureTaskDefinition"
+
+	querry := qb.Insert("task_definitions").
+		Columns("task_id", "title").
+		Values(taskID, taskID).
+		Suffix("ON CONFLICT (task_id) DO NOTHING")
+
+	return s.execTx(ctx, tx, op, querry)
+}
+
+func taskDefinitionsQuerry() sq.SelectBuilder {
+	return qb.Select("d.task_id", "COALESCE(d.project_id, 0)", "COALESCE(p.name, '')", "d.title", "d.description").
+		From("task_definitions d").
+		LeftJoin("projects p ON p.id = d.project_id")
+}
+
+func nullableID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
@@ -2,22 +2,26 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/njslxve/time-tracker-service/internal/model/entity"
 )
 
 type Storage struct {
 	logger *slog.Logger
-	db     *pgx.Conn
+	db     *pgxpool.Pool
 }
 
-func New(logger *slog.Logger, client *pgx.Conn) *Storage {
+func New(logger *slog.Logger, client *pgxpool.Pool) *Storage {
 	return &Storage{
 		logger: logger,
 		db:     client,
@@ -28,47 +32,74 @@ var (
 	qb = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 )
 
-func (s *Storage) AddUser(user entity.User) error {
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "transport.storage.Ping"
+
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// AddUser creates a user pending enrichment and queues the job that fills in
+// their personal data from the people info service.
+func (s *Storage) AddUser(ctx context.Context, user entity.User) (int, error) {
 	const op = "transport.storage.AddUser"
 
-	uuid := uuid.NewString()
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
 
 	querry := qb.Insert("users").
-		Columns("id", "passport", "first_name", "last_name", "patronymic", "adress").
-		Values(uuid, user.Passport, user.Name, user.Surmame, user.Patronymic, user.Adress)
+		Columns("id", "passport", "first_name", "last_name", "patronymic", "adress", "status").
+		Values(uuid.NewString(), user.Passport, user.Name, user.Surmame, user.Patronymic, user.Adress, entity.UserPendingEnrichment)
 
-	sql, args, err := querry.ToSql()
+	userID, err := s.createTx(ctx, tx, op, "users", "user_id", querry)
 	if err != nil {
-		s.logger.Debug("could not convert query to sql",
-			slog.String("description", op),
-			slog.String("error", err.Error()),
-		)
-
-		return fmt.Errorf("%s: %w", op, err)
+		return 0, err
 	}
 
-	_, err = s.db.Exec(context.Background(), sql, args...)
-	if err != nil {
-		s.logger.Debug("sql error",
-			slog.String("description", op),
-			slog.String("sql", sql),
-			slog.Any("args", args),
-			slog.String("error", err.Error()),
-		)
+	jobQuerry := qb.Insert("enrichment_jobs").
+		Columns("user_id").
+		Values(userID)
 
-		return fmt.Errorf("%s: %w", op, err)
+	if err := s.execTx(ctx, tx, op, jobQuerry); err != nil {
+		return 0, err
 	}
 
-	return nil
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
 }
 
-func (s *Storage) GetUser(userID int) (entity.User, error) {
+func (s *Storage) GetUser(ctx context.Context, userID int) (entity.User, error) {
 	const op = "transport.storage.GetUser"
 
-	querry := qb.Select("user_id", "passport", "first_name", "last_name", "patronymic", "adress").
+	return s.getUser(ctx, op, userID, false)
+}
+
+// LockUser is GetUser that also locks the row of the user until the unit of
+// work of ctx ends.
+func (s *Storage) LockUser(ctx context.Context, userID int) (entity.User, error) {
+	const op = "transport.storage.LockUser"
+
+	return s.getUser(ctx, op, userID, true)
+}
+
+func (s *Storage) getUser(ctx context.Context, op string, userID int, lock bool) (entity.User, error) {
+	querry := qb.Select("user_id", "passport", "first_name", "last_name", "patronymic", "adress", "status", "overridden_fields", "deleted_at").
 		From("users").
 		Where(sq.Eq{"user_id": userID})
 
+	if lock {
+		querry = querry.Suffix("FOR UPDATE")
+	}
+
 	sql, args, err := querry.ToSql()
 	if err != nil {
 		s.logger.Debug("could not convert query to sql",
@@ -79,11 +110,11 @@ func (s *Storage) GetUser(userID int) (entity.User, error) {
 		return entity.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	row := s.db.QueryRow(context.Background(), sql, args...)
+	row := s.conn(ctx).QueryRow(ctx, sql, args...)
 
 	var user entity.User
 
-	err = row.Scan(&user.UserID, &user.Passport, &user.Name, &user.Surmame, &user.Patronymic, &user.Adress)
+	err = row.Scan(&user.UserID, &user.Passport, &user.Name, &user.Surmame, &user.Patronymic, &user.Adress, &user.Status, &user.Overridden, &user.DeletedAt)
 	if err != nil {
 		s.logger.Debug("could not scan row",
 			slog.String("description", op),
@@ -96,26 +127,42 @@ func (s *Storage) GetUser(userID int) (entity.User, error) {
 	return user, nil
 }
 
-func (s *Storage) GetUsers(opts entity.FilterOptions) ([]entity.User, error) {
+// GetUsers returns the page of users matching opts in the order of the page,
+// with ties broken by user_id. User.SortKey holds the value sorted by.
+func (s *Storage) GetUsers(ctx context.Context, opts entity.FilterOptions, page entity.Page) ([]entity.User, error) {
 	const op = "transport.storage.GetUsers"
 
-	querry := qb.Select("user_id", "passport", "first_name", "last_name", "patronymic", "adress").
-		From("users")
+	key, keyArgs := userSortKey(opts, page.Sort)
 
-	if opts.Name != "" {
-		querry = querry.Where(sq.Eq{"first_name": opts.Name})
-	}
+	// A page before the cursor is read in reverse and turned around below.
+	backward := page.BeforeID > 0
+	descending := (page.Order == entity.SortDesc) != backward
 
-	if opts.Surname != "" {
-		querry = querry.Where(sq.Eq{"last_name": opts.Surname})
+	cmp, direction := ">", "ASC"
+	if descending {
+		cmp, direction = "<", "DESC"
 	}
 
-	if opts.Patronymic != "" {
-		querry = querry.Where(sq.Eq{"patronymic": opts.Patronymic})
+	querry := qb.Select("user_id", "passport", "first_name", "last_name", "patronymic", "adress", "status", "overridden_fields", "deleted_at").
+		Column(sq.Expr(key, keyArgs...)).
+		From("users").
+		Where(userFilter(opts)).
+		Limit(uint64(page.Limit))
+
+	if page.Sort != entity.SortUserID {
+		querry = querry.OrderByClause(sq.Expr(key+" "+direction, keyArgs...))
 	}
 
-	if opts.Adress != "" {
-		querry = querry.Where(sq.ILike{"adress": fmt.Sprintf("%%%s%%", opts.Adress)})
+	querry = querry.OrderBy("user_id " + direction)
+
+	pivot := max(page.AfterID, page.BeforeID)
+
+	switch {
+	case pivot == 0:
+	case page.Sort == entity.SortUserID:
+		querry = querry.Where("user_id "+cmp+" ?", pivot)
+	default:
+		querry = querry.Where(sq.Expr("("+key+", user_id) "+cmp+" (?, ?)", append(keyArgs, page.Key, pivot)...))
 	}
 
 	sql, args, err := querry.ToSql()
@@ -128,7 +175,7 @@ func (s *Storage) GetUsers(opts entity.FilterOptions) ([]entity.User, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rows, err := s.db.Query(context.Background(), sql, args...)
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -147,7 +194,7 @@ func (s *Storage) GetUsers(opts entity.FilterOptions) ([]entity.User, error) {
 	for rows.Next() {
 		var user entity.User
 
-		err = rows.Scan(&user.UserID, &user.Passport, &user.Name, &user.Surmame, &user.Patronymic, &user.Adress)
+		err = rows.Scan(&user.UserID, &user.Passport, &user.Name, &user.Surmame, &user.Patronymic, &user.Adress, &user.Status, &user.Overridden, &user.DeletedAt, &user.SortKey)
 		if err != nil {
 			s.logger.Debug("could not scan row",
 				slog.String("description", op),
@@ -160,23 +207,179 @@ func (s *Storage) GetUsers(opts entity.FilterOptions) ([]entity.User, error) {
 		users = append(users, user)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if backward {
+		slices.Reverse(users)
+	}
+
 	return users, nil
 }
 
-func (s *Storage) UpdateUser(user entity.User) error {
+func userFilter(opts entity.FilterOptions) sq.And {
+	filter := sq.And{}
+
+	if opts.Name != "" {
+		filter = append(filter, match("first_name", opts.Name, opts.NameMatch))
+	}
+
+	if opts.Surname != "" {
+		filter = append(filter, match("last_name", opts.Surname, opts.SurnameMatch))
+	}
+
+	if opts.Patronymic != "" {
+		filter = append(filter, match("patronymic", opts.Patronymic, opts.PatronymicMatch))
+	}
+
+	if opts.Adress != "" {
+		mode := opts.AdressMatch
+		if mode == "" {
+			mode = entity.MatchContains
+		}
+
+		filter = append(filter, match("adress", opts.Adress, mode))
+	}
+
+	if opts.Query != "" {
+		filter = append(filter, sq.Expr("("+userSearchVector+" @@ websearch_to_tsquery('simple', ?) OR ? <% ("+userSearchDocument+"))", opts.Query, opts.Query))
+	}
+
+	if opts.PassportSeries != "" {
+		filter = append(filter, sq.Like{"passport": opts.PassportSeries + " %"})
+	}
+
+	if !opts.IncludeDeleted {
+		filter = append(filter, sq.Eq{"users.deleted_at": nil})
+	}
+
+	if opts.ManagerID != 0 {
+		filter = append(filter, sq.Expr("users.user_id IN (SELECT m.user_id FROM team_members m JOIN teams tm ON tm.id = m.team_id WHERE tm.manager_id = ?)", opts.ManagerID))
+	}
+
+	return filter
+}
+
+// The text searched by FilterOptions.Query. Both expressions are indexed and
+// must match the migration exactly.
+const (
+	userSearchDocument = "first_name || ' ' || last_name || ' ' || COALESCE(patronymic, '') || ' ' || adress"
+	userSearchVector   = "to_tsvector('simple', " + userSearchDocument + ")"
+)
+
+// userSortKey returns the expression users are ordered by for sort.
+func userSortKey(opts entity.FilterOptions, sort string) (string, []interface{}) {
+	switch sort {
+	case entity.SortName:
+		return "first_name", nil
+	case entity.SortSurname:
+		return "last_name", nil
+	case entity.SortRelevance:
+		return "(ts_rank(" + userSearchVector + ", websearch_to_tsquery('simple', ?)) + word_similarity(?, " + userSearchDocument + "))::float8", []interface{}{opts.Query, opts.Query}
+	default:
+		return "user_id", nil
+	}
+}
+
+// match compares column with value the way mode says, exact by default.
+func match(column string, value string, mode string) sq.Sqlizer {
+	switch mode {
+	case entity.MatchPrefix:
+		return sq.ILike{column: escapeLike(value) + "%"}
+	case entity.MatchContains:
+		return sq.ILike{column: "%" + escapeLike(value) + "%"}
+	case entity.MatchFuzzy:
+		return sq.Expr(column+" % ?", value)
+	default:
+		return sq.ILike{column: escapeLike(value)}
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// summaryFilter keeps users archived during or after the period in the
+// summary, so departed employees still account for the time they tracked.
+func summaryFilter(opts entity.SummaryOptions) sq.And {
+	if opts.Filter.IncludeDeleted {
+		return userFilter(opts.Filter)
+	}
+
+	filter := opts.Filter
+	filter.IncludeDeleted = true
+
+	return append(userFilter(filter), sq.Or{
+		sq.Eq{"users.deleted_at": nil},
+		sq.Gt{"users.deleted_at": opts.From},
+	})
+}
+
+// timesheetFilter never drops archived users: a timesheet lists sessions, and
+// those stay attributed to whoever tracked them.
+func timesheetFilter(opts entity.FilterOptions) sq.And {
+	opts.IncludeDeleted = true
+
+	return userFilter(opts)
+}
+
+func (s *Storage) UpdateUser(ctx context.Context, user entity.User) error {
 	const op = "transport.storage.UpdateUser"
 
+	if user.Overridden == nil {
+		user.Overridden = []string{}
+	}
+
 	querry := qb.Update("users").
 		SetMap(map[string]interface{}{
-			"passport":   user.Passport,
-			"first_name": user.Name,
-			"last_name":  user.Surmame,
-			"patronymic": user.Patronymic,
-			"adress":     user.Adress,
+			"passport":          user.Passport,
+			"first_name":        user.Name,
+			"last_name":         user.Surmame,
+			"patronymic":        user.Patronymic,
+			"adress":            user.Adress,
+			"overridden_fields": user.Overridden,
 		}).
 		Where(sq.Eq{"user_id": user.UserID})
 
-	sql, args, err := querry.ToSql()
+	return s.audited(ctx, op, userChange(user.UserID, auditUpdate), querry)
+}
+
+// ArchiveUser marks the user as deleted and stops their running task, if any.
+// Tracked time is kept so that past reports stay intact.
+func (s *Storage) ArchiveUser(ctx context.Context, userID int, at time.Time) error {
+	const op = "transport.storage.ArchiveUser"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	querry := qb.Update("users").
+		Set("deleted_at", at).
+		Where(sq.And{
+			sq.Eq{"user_id": userID},
+			sq.Eq{"deleted_at": nil},
+		})
+
+	err = s.record(ctx, tx, op, userChange(userID, auditArchive), func() error {
+		return s.execTx(ctx, tx, op, querry)
+	})
+	if err != nil {
+		return err
+	}
+
+	runningQuerry := qb.Select("id", "user_id", "task_id", "start_time").
+		From("tasks").
+		Where(sq.And{
+			sq.Eq{"user_id": userID},
+			sq.Eq{"end_time": nil},
+		})
+
+	sql, args, err := runningQuerry.ToSql()
 	if err != nil {
 		s.logger.Debug("could not convert query to sql",
 			slog.String("description", op),
@@ -186,8 +389,10 @@ func (s *Storage) UpdateUser(user entity.User) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.db.Exec(context.Background(), sql, args...)
-	if err != nil {
+	var running entity.Task
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&running.ID, &running.UserID, &running.TaskID, &running.StartTime)
+	if err != nil && !errors.Is(err, ErrNotFound) {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
 			slog.String("sql", sql),
@@ -198,13 +403,96 @@ func (s *Storage) UpdateUser(user entity.User) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if running.ID != "" {
+		running.EndTime = at
+
+		err = s.record(ctx, tx, op, taskChange(running.ID, auditStop), func() error {
+			return s.stopTask(ctx, tx, running)
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	return nil
 }
 
-func (s *Storage) DeleteUser(userID int) error {
-	const op = "transport.storage.DeleteUser"
+func (s *Storage) RestoreUser(ctx context.Context, userID int) error {
+	const op = "transport.storage.RestoreUser"
+
+	querry := qb.Update("users").
+		Set("deleted_at", nil).
+		Where(sq.And{
+			sq.Eq{"user_id": userID},
+			sq.NotEq{"deleted_at": nil},
+		})
+
+	return s.audited(ctx, op, userChange(userID, auditRestore), querry)
+}
+
+// PurgeUser irreversibly removes an archived user together with their tracked
+// time. Segments, roles, team memberships and API keys go with them through
+// their foreign keys. The audit log keeps the history of the user, the purge
+// included, without their personal data.
+func (s *Storage) PurgeUser(ctx context.Context, userID int) error {
+	const op = "transport.storage.PurgeUser"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tasksQuerry := qb.Delete("tasks").
+		Where(sq.Eq{"user_id": userID})
 
 	querry := qb.Delete("users").
+		Where(sq.And{
+			sq.Eq{"user_id": userID},
+			sq.NotEq{"deleted_at": nil},
+		})
+
+	err = s.record(ctx, tx, op, userChange(userID, auditPurge), func() error {
+		if err := s.execTx(ctx, tx, op, tasksQuerry); err != nil {
+			return err
+		}
+
+		return s.execTxAffecting(ctx, tx, op, querry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.redactUser(ctx, tx, op, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SetCalendarToken(ctx context.Context, userID int, tokenHash string) error {
+	const op = "transport.storage.SetCalendarToken"
+
+	querry := qb.Update("users").
+		Set("calendar_token", tokenHash).
+		Where(sq.Eq{"user_id": userID})
+
+	return s.audited(ctx, op, userChange(userID, auditRotateToken), querry)
+}
+
+func (s *Storage) CalendarToken(ctx context.Context, userID int) (string, error) {
+	const op = "transport.storage.CalendarToken"
+
+	querry := qb.Select("COALESCE(calendar_token, '')").
+		From("users").
 		Where(sq.Eq{"user_id": userID})
 
 	sql, args, err := querry.ToSql()
@@ -214,10 +502,12 @@ func (s *Storage) DeleteUser(userID int) error {
 			slog.String("error", err.Error()),
 		)
 
-		return fmt.Errorf("%s: %w", op, err)
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.db.Exec(context.Background(), sql, args...)
+	var tokenHash string
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&tokenHash)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -226,32 +516,42 @@ func (s *Storage) DeleteUser(userID int) error {
 			slog.String("error", err.Error()),
 		)
 
-		return fmt.Errorf("%s: %w", op, err)
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return tokenHash, nil
 }
 
-func (s *Storage) AddTask(task entity.Task) error {
+func (s *Storage) AddTask(ctx context.Context, task entity.Task, stopRunning bool) (entity.Task, error) {
 	const op = "transport.storage.AddTask"
 
-	uuid := uuid.NewString()
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
 
-	querry := qb.Insert("tasks").
-		Columns("id", "user_id", "task_id", "start_time").
-		Values(uuid, task.UserID, task.TaskID, task.StartTime)
+	lockQuerry := qb.Select("user_id").
+		From("users").
+		Where(sq.And{
+			sq.Eq{"user_id": task.UserID},
+			sq.Eq{"deleted_at": nil},
+		}).
+		Suffix("FOR UPDATE")
 
-	sql, args, err := querry.ToSql()
+	sql, args, err := lockQuerry.ToSql()
 	if err != nil {
 		s.logger.Debug("could not convert query to sql",
 			slog.String("description", op),
 			slog.String("error", err.Error()),
 		)
 
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.db.Exec(context.Background(), sql, args...)
+	var userID int
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&userID)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -260,22 +560,141 @@ func (s *Storage) AddTask(task entity.Task) error {
 			slog.String("error", err.Error()),
 		)
 
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	runningQuerry := qb.Select("id", "user_id", "task_id", "start_time").
+		From("tasks").
+		Where(sq.And{
+			sq.Eq{"user_id": task.UserID},
+			sq.Eq{"end_time": nil},
+		})
+
+	sql, args, err = runningQuerry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var running entity.Task
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&running.ID, &running.UserID, &running.TaskID, &running.StartTime)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if running.ID != "" {
+		if !stopRunning {
+			return running, nil
+		}
+
+		running.EndTime = task.StartTime
+
+		err = s.record(ctx, tx, op, taskChange(running.ID, auditStop), func() error {
+			return s.stopTask(ctx, tx, running)
+		})
+		if err != nil {
+			return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := s.ensureTaskDefinition(ctx, tx, task.TaskID); err != nil {
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	task.ID = uuid.NewString()
+
+	err = s.record(ctx, tx, op, taskChange(task.ID, auditStart), func() error {
+		querry := qb.Insert("tasks").
+			Columns("id", "user_id", "task_id", "start_time").
+			Values(task.ID, task.UserID, task.TaskID, task.StartTime)
+
+		if err := s.execTx(ctx, tx, op, querry); err != nil {
+			return err
+		}
+
+		segmentQuerry := qb.Insert("task_segments").
+			Columns("id", "task_uuid", "start_time").
+			Values(uuid.NewString(), task.ID, task.StartTime)
+
+		return s.execTx(ctx, tx, op, segmentQuerry)
+	})
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return running, nil
+}
+
+func (s *Storage) stopTask(ctx context.Context, tx pgx.Tx, task entity.Task) error {
+	const op = "transport.storage.stopTask"
+
+	segmentQuerry := qb.Update("task_segments").
+		Set("end_time", task.EndTime).
+		Where(sq.And{
+			sq.Eq{"task_uuid": task.ID},
+			sq.Eq{"end_time": nil},
+		})
+
+	if err := s.execTx(ctx, tx, op, segmentQuerry); err != nil {
+		return err
+	}
+
+	querry := qb.Update("tasks").
+		Set("end_time", task.EndTime).
+		Where(sq.Eq{"id": task.ID})
+
+	if err := s.execTx(ctx, tx, op, querry); err != nil {
+		return err
+	}
+
+	return s.updateDuration(ctx, tx, task)
 }
 
-func (s *Storage) GetTask(taskID string, userID int) (entity.Task, error) {
+// GetTask returns the running task taskID of the user.
+func (s *Storage) GetTask(ctx context.Context, taskID string, userID int) (entity.Task, error) {
 	const op = "transport.storage.GetTask"
 
+	return s.getTask(ctx, op, taskID, userID, false)
+}
+
+// LockTask is GetTask that also locks the row of the task until the unit of
+// work of ctx ends. A request that waited for the lock of a task another one
+// ended gets ErrNotFound, since the task is no longer running.
+func (s *Storage) LockTask(ctx context.Context, taskID string, userID int) (entity.Task, error) {
+	const op = "transport.storage.LockTask"
+
+	return s.getTask(ctx, op, taskID, userID, true)
+}
+
+func (s *Storage) getTask(ctx context.Context, op string, taskID string, userID int, lock bool) (entity.Task, error) {
 	querry := qb.Select("id", "user_id", "task_id", "start_time").
 		From("tasks").
 		Where(sq.And{
 			sq.Eq{"user_id": userID},
 			sq.Eq{"task_id": taskID},
+			sq.Eq{"end_time": nil},
 		})
 
+	if lock {
+		querry = querry.Suffix("FOR UPDATE")
+	}
+
 	sql, args, err := querry.ToSql()
 	if err != nil {
 		s.logger.Debug("could not convert query to sql",
@@ -288,7 +707,7 @@ func (s *Storage) GetTask(taskID string, userID int) (entity.Task, error) {
 
 	var task entity.Task
 
-	err = s.db.QueryRow(context.Background(), sql, args...).Scan(&task.ID, &task.UserID, &task.TaskID, &task.StartTime)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&task.ID, &task.UserID, &task.TaskID, &task.StartTime)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -303,20 +722,22 @@ func (s *Storage) GetTask(taskID string, userID int) (entity.Task, error) {
 	return task, nil
 }
 
-func (s *Storage) GetTasks(userID int, interval int) ([]entity.Task, error) {
+func (s *Storage) GetTasks(ctx context.Context, userID int, interval int) ([]entity.Task, error) {
 	const op = "transport.storage.GetTasks"
 
-	querry := qb.Select("user_id", "task_id", "start_time", "end_time", "duration").
-		From("tasks").
+	querry := qb.Select("t.id", "t.user_id", "t.task_id", "t.start_time", "t.end_time", "t.duration", "COALESCE(d.title, t.task_id)", "COALESCE(p.id, 0)", "COALESCE(p.name, '')").
+		From("tasks t").
+		LeftJoin("task_definitions d ON d.task_id = t.task_id").
+		LeftJoin("projects p ON p.id = d.project_id").
 		Where(sq.And{
-			sq.Eq{"user_id": userID},
-			sq.NotEq{"duration": nil},
+			sq.Eq{"t.user_id": userID},
+			sq.NotEq{"t.duration": nil},
 		}).
-		OrderBy("duration DESC")
+		OrderBy("t.duration DESC")
 
 	if interval != 0 {
 		querry = querry.
-			Where(fmt.Sprintf("start_time >= current_date - interval '%d days'", interval))
+			Where("t.start_time >= current_date - make_interval(days => ?)", interval)
 	}
 
 	sql, args, err := querry.ToSql()
@@ -329,7 +750,7 @@ func (s *Storage) GetTasks(userID int, interval int) ([]entity.Task, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rows, err := s.db.Query(context.Background(), sql, args...)
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -341,10 +762,12 @@ func (s *Storage) GetTasks(userID int, interval int) ([]entity.Task, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	defer rows.Close()
+
 	var tasks []entity.Task
 	for rows.Next() {
 		var task entity.Task
-		err = rows.Scan(&task.UserID, &task.TaskID, &task.StartTime, &task.EndTime, &task.Duration)
+		err = rows.Scan(&task.ID, &task.UserID, &task.TaskID, &task.StartTime, &task.EndTime, &task.Duration, &task.Title, &task.ProjectID, &task.ProjectName)
 		if err != nil {
 			s.logger.Debug("sql error",
 				slog.String("description", op),
@@ -359,18 +782,31 @@ func (s *Storage) GetTasks(userID int, interval int) ([]entity.Task, error) {
 		tasks = append(tasks, task)
 	}
 
-	return tasks, nil
-}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-func (s *Storage) UpdateTask(task entity.Task) error {
-	const op = "transport.storage.UpdateTask"
+	return tasks, nil
+}
 
-	querry := qb.Update("tasks").
-		SetMap(sq.Eq{"end_time": task.EndTime, "duration": task.Duration}).
+func (s *Storage) GetTaskTotals(ctx context.Context, userID int, interval int) ([]entity.TaskTotal, error) {
+	const op = "transport.storage.GetTaskTotals"
+
+	querry := qb.Select("t.task_id", "COALESCE(d.title, t.task_id)", "COALESCE(p.name, '')", "t.user_id", "count(*)", "min(t.start_time)", "max(t.end_time)", "sum(t.duration)").
+		From("tasks t").
+		LeftJoin("task_definitions d ON d.task_id = t.task_id").
+		LeftJoin("projects p ON p.id = d.project_id").
 		Where(sq.And{
-			sq.Eq{"id": task.ID},
-			sq.Eq{"user_id": task.UserID},
-		})
+			sq.Eq{"t.user_id": userID},
+			sq.NotEq{"t.duration": nil},
+		}).
+		GroupBy("t.task_id", "d.title", "p.name", "t.user_id").
+		OrderBy("sum(t.duration) DESC")
+
+	if interval != 0 {
+		querry = querry.
+			Where("t.start_time >= current_date - make_interval(days => ?)", interval)
+	}
 
 	sql, args, err := querry.ToSql()
 	if err != nil {
@@ -379,10 +815,10 @@ func (s *Storage) UpdateTask(task entity.Task) error {
 			slog.String("error", err.Error()),
 		)
 
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.db.Exec(context.Background(), sql, args...)
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -391,18 +827,153 @@ func (s *Storage) UpdateTask(task entity.Task) error {
 			slog.String("error", err.Error()),
 		)
 
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var totals []entity.TaskTotal
+
+	for rows.Next() {
+		var total entity.TaskTotal
+
+		err = rows.Scan(&total.TaskID, &total.Title, &total.ProjectName, &total.UserID, &total.Sessions, &total.FirstStart, &total.LastEnd, &total.Duration)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		totals = append(totals, total)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return totals, nil
+}
+
+func (s *Storage) AddSession(ctx context.Context, task entity.Task) error {
+	const op = "transport.storage.AddSession"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.ensureTaskDefinition(ctx, tx, task.TaskID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	task.ID = uuid.NewString()
+
+	querry := qb.Insert("tasks").
+		Columns("id", "user_id", "task_id", "start_time", "end_time").
+		Values(task.ID, task.UserID, task.TaskID, task.StartTime, task.EndTime)
+
+	err = s.record(ctx, tx, op, taskChange(task.ID, auditCreate), func() error {
+		if err := s.execTx(ctx, tx, op, querry); err != nil {
+			return err
+		}
+
+		if err := s.replaceSegments(ctx, tx, task); err != nil {
+			return err
+		}
+
+		return s.updateDuration(ctx, tx, task)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
-func (s *Storage) TokenData(token string) (int, entity.TokenData, error) {
-	const op = "transport.storage.TokenData"
+func (s *Storage) GetSession(ctx context.Context, id string) (entity.Task, error) {
+	const op = "transport.storage.GetSession"
+
+	return s.getSession(ctx, op, id, false)
+}
+
+// LockSession is GetSession that also locks the row of the session until the
+// unit of work of ctx ends.
+func (s *Storage) LockSession(ctx context.Context, id string) (entity.Task, error) {
+	const op = "transport.storage.LockSession"
+
+	return s.getSession(ctx, op, id, true)
+}
+
+func (s *Storage) getSession(ctx context.Context, op string, id string, lock bool) (entity.Task, error) {
+	querry := qb.Select("id", "user_id", "task_id", "start_time", "end_time").
+		From("tasks").
+		Where(sq.Eq{"id": id})
+
+	if lock {
+		querry = querry.Suffix("FOR UPDATE")
+	}
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		task    entity.Task
+		endTime *time.Time
+	)
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&task.ID, &task.UserID, &task.TaskID, &task.StartTime, &endTime)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if endTime != nil {
+		task.EndTime = *endTime
+	}
+
+	return task, nil
+}
+
+func (s *Storage) HasOverlap(ctx context.Context, task entity.Task) (bool, error) {
+	const op = "transport.storage.HasOverlap"
+
+	querry := qb.Select("1").
+		From("tasks").
+		Where(sq.And{
+			sq.Eq{"user_id": task.UserID},
+			sq.Expr("COALESCE(end_time, 'infinity') > ?", task.StartTime),
+		}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")")
+
+	if !task.EndTime.IsZero() {
+		querry = querry.Where(sq.Lt{"start_time": task.EndTime})
+	}
 
-	querry := qb.Select("old_limit", "filter_params", "is_alive").
-		From("pagination_tokens").
-		Where(sq.Eq{"token": token})
+	if task.ID != "" {
+		querry = querry.Where(sq.NotEq{"id": task.ID})
+	}
 
 	sql, args, err := querry.ToSql()
 	if err != nil {
@@ -411,12 +982,12 @@ func (s *Storage) TokenData(token string) (int, entity.TokenData, error) {
 			slog.String("error", err.Error()),
 		)
 
-		return 0, entity.TokenData{}, fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var tokenData entity.TokenData
+	var overlaps bool
 
-	err = s.db.QueryRow(context.Background(), sql, args...).Scan(&tokenData.Old, &tokenData.Params, &tokenData.IsAlive)
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&overlaps)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -425,18 +996,181 @@ func (s *Storage) TokenData(token string) (int, entity.TokenData, error) {
 			slog.String("error", err.Error()),
 		)
 
-		return 0, entity.TokenData{}, fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return tokenData.Old, tokenData, nil
+	return overlaps, nil
 }
 
-func (s *Storage) AddToken(token string, limit int, params []byte) error {
-	const op = "transport.storage.AddToken"
+func (s *Storage) GetUserReport(ctx context.Context, opts entity.ReportOptions) ([]entity.ReportRow, error) {
+	const op = "transport.storage.GetUserReport"
+
+	key, title, project := reportGroupColumns(opts.GroupBy)
+
+	querry := qb.Select().
+		Column(sq.Alias(sq.Expr(key), "period")).
+		Column(title).
+		Column(project).
+		Column(sq.Expr("floor(extract(epoch FROM sum(LEAST(COALESCE(s.end_time, now()), ?::timestamptz) - GREATEST(s.start_time, ?::timestamptz))) / 60)::int", opts.To, opts.From)).
+		From("task_segments s").
+		Join("tasks t ON t.id = s.task_uuid").
+		LeftJoin("task_definitions d ON d.task_id = t.task_id").
+		LeftJoin("projects p ON p.id = d.project_id").
+		Where(sq.And{
+			sq.Eq{"t.user_id": opts.UserID},
+			sq.Lt{"s.start_time": opts.To},
+			sq.Expr("COALESCE(s.end_time, now()) > ?", opts.From),
+		}).
+		GroupBy("period")
 
-	querry := qb.Insert("pagination_tokens").
-		Columns("token", "old_limit", "filter_params", "created_at").
-		Values(token, limit, params, time.Now())
+	if opts.GroupBy == entity.GroupByTask || opts.GroupBy == entity.GroupByProject {
+		querry = querry.OrderBy("4 DESC")
+	} else {
+		querry = querry.OrderBy("period")
+	}
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var report []entity.ReportRow
+
+	for rows.Next() {
+		var row entity.ReportRow
+
+		err = rows.Scan(&row.Key, &row.Title, &row.ProjectName, &row.Duration)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		report = append(report, row)
+	}
+
+	return report, nil
+}
+
+func (s *Storage) GetSummaryReport(ctx context.Context, opts entity.SummaryOptions) ([]entity.UserTotal, int, error) {
+	const op = "transport.storage.GetSummaryReport"
+
+	querry := qb.Select("users.user_id", "first_name", "last_name", "patronymic").
+		Column(sq.Expr("COALESCE(floor(extract(epoch FROM sum(LEAST(COALESCE(s.end_time, now()), ?::timestamptz) - GREATEST(s.start_time, ?::timestamptz)) FILTER (WHERE s.id IS NOT NULL)) / 60), 0)::int AS minutes", opts.To, opts.From)).
+		Column("count(*) OVER ()").
+		From("users").
+		LeftJoin("tasks t ON t.user_id = users.user_id").
+		LeftJoin("task_segments s ON s.task_uuid = t.id AND s.start_time < ? AND COALESCE(s.end_time, now()) > ?", opts.To, opts.From).
+		Where(summaryFilter(opts)).
+		GroupBy("users.user_id", "first_name", "last_name", "patronymic").
+		Limit(uint64(opts.Limit)).
+		Offset(uint64(opts.Offset))
+
+	if opts.Sort == entity.SortAsc {
+		querry = querry.OrderBy("minutes ASC", "users.user_id")
+	} else {
+		querry = querry.OrderBy("minutes DESC", "users.user_id")
+	}
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var (
+		totals []entity.UserTotal
+		count  int
+	)
+
+	for rows.Next() {
+		var (
+			total      entity.UserTotal
+			patronymic *string
+		)
+
+		err = rows.Scan(&total.UserID, &total.Name, &total.Surname, &patronymic, &total.Duration, &count)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if patronymic != nil {
+			total.Patronymic = *patronymic
+		}
+
+		totals = append(totals, total)
+	}
+
+	return totals, count, nil
+}
+
+func (s *Storage) Timesheet(ctx context.Context, opts entity.TimesheetOptions, fn func(entity.TimesheetRow) error) error {
+	const op = "transport.storage.Timesheet"
+
+	querry := qb.Select("t.id", "users.user_id", "first_name", "last_name", "patronymic", "t.task_id", "COALESCE(d.title, t.task_id)", "COALESCE(p.name, '')", "t.start_time", "t.end_time", "t.duration").
+		From("tasks t").
+		Join("users ON users.user_id = t.user_id").
+		LeftJoin("task_definitions d ON d.task_id = t.task_id").
+		LeftJoin("projects p ON p.id = d.project_id").
+		Where(sq.And{
+			sq.GtOrEq{"t.start_time": opts.From},
+			sq.Lt{"t.start_time": opts.To},
+		}).
+		Where(timesheetFilter(opts.Filter)).
+		OrderBy("users.user_id", "t.start_time")
+
+	if !opts.IncludeOpen {
+		querry = querry.Where(sq.NotEq{"t.duration": nil})
+	}
+
+	if opts.UserID != 0 {
+		querry = querry.Where(sq.Eq{"t.user_id": opts.UserID})
+	}
 
 	sql, args, err := querry.ToSql()
 	if err != nil {
@@ -448,7 +1182,7 @@ func (s *Storage) AddToken(token string, limit int, params []byte) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.db.Exec(context.Background(), sql, args...)
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		s.logger.Debug("sql error",
 			slog.String("description", op),
@@ -460,5 +1194,285 @@ func (s *Storage) AddToken(token string, limit int, params []byte) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row        entity.TimesheetRow
+			patronymic *string
+			endTime    *time.Time
+			duration   *int
+		)
+
+		err = rows.Scan(&row.SessionID, &row.UserID, &row.Name, &row.Surname, &patronymic, &row.TaskID, &row.Title, &row.ProjectName, &row.StartTime, &endTime, &duration)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if patronymic != nil {
+			row.Patronymic = *patronymic
+		}
+
+		if endTime != nil {
+			row.EndTime = *endTime
+		}
+
+		if duration != nil {
+			row.Duration = *duration
+		}
+
+		if err := fn(row); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	return nil
 }
+
+func reportGroupColumns(groupBy string) (string, string, string) {
+	switch groupBy {
+	case entity.GroupByDay:
+		return "to_char(date_trunc('day', s.start_time), 'YYYY-MM-DD')", "''", "''"
+	case entity.GroupByWeek:
+		return `to_char(date_trunc('week', s.start_time), 'IYYY-"W"IW')`, "''", "''"
+	case entity.GroupByMonth:
+		return "to_char(date_trunc('month', s.start_time), 'YYYY-MM')", "''", "''"
+	case entity.GroupByProject:
+		return "COALESCE(p.name, '')", "''", "COALESCE(p.name, '')"
+	default:
+		return "t.task_id", "COALESCE(max(d.title), max(t.task_id))", "COALESCE(max(p.name), '')"
+	}
+}
+
+func (s *Storage) UpdateTask(ctx context.Context, task entity.Task) error {
+	const op = "transport.storage.UpdateTask"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var endTime *time.Time
+	if !task.EndTime.IsZero() {
+		endTime = &task.EndTime
+	}
+
+	querry := qb.Update("tasks").
+		SetMap(sq.Eq{"start_time": task.StartTime, "end_time": endTime}).
+		Where(sq.And{
+			sq.Eq{"id": task.ID},
+			sq.Eq{"user_id": task.UserID},
+		})
+
+	err = s.record(ctx, tx, op, taskChange(task.ID, auditUpdate), func() error {
+		if err := s.execTx(ctx, tx, op, querry); err != nil {
+			return err
+		}
+
+		if task.Segments != nil {
+			if err := s.replaceSegments(ctx, tx, task); err != nil {
+				return err
+			}
+		}
+
+		return s.updateDuration(ctx, tx, task)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) replaceSegments(ctx context.Context, tx pgx.Tx, task entity.Task) error {
+	const op = "transport.storage.replaceSegments"
+
+	deleteQuerry := qb.Delete("task_segments").
+		Where(sq.Eq{"task_uuid": task.ID})
+
+	if err := s.execTx(ctx, tx, op, deleteQuerry); err != nil {
+		return err
+	}
+
+	if len(task.Segments) == 0 {
+		return nil
+	}
+
+	insertQuerry := qb.Insert("task_segments").
+		Columns("id", "task_uuid", "start_time", "end_time")
+
+	for _, segment := range task.Segments {
+		var endTime *time.Time
+		if !segment.EndTime.IsZero() {
+			endTime = &segment.EndTime
+		}
+
+		insertQuerry = insertQuerry.Values(uuid.NewString(), task.ID, segment.StartTime, endTime)
+	}
+
+	return s.execTx(ctx, tx, op, insertQuerry)
+}
+
+func (s *Storage) updateDuration(ctx context.Context, tx pgx.Tx, task entity.Task) error {
+	const op = "transport.storage.updateDuration"
+
+	querry := qb.Update("tasks").
+		Set("duration", sq.Expr("CASE WHEN end_time IS NULL THEN NULL ELSE (SELECT COALESCE(floor(extract(epoch FROM sum(end_time - start_time)) / 60), 0) FROM task_segments WHERE task_uuid = ?) END", task.ID)).
+		Where(sq.Eq{"id": task.ID})
+
+	return s.execTx(ctx, tx, op, querry)
+}
+
+func (s *Storage) exec(ctx context.Context, op string, querry sq.Sqlizer) error {
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := s.conn(ctx).Exec(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) execTx(ctx context.Context, tx pgx.Tx, op string, querry sq.Sqlizer) error {
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) AddSegment(ctx context.Context, segment entity.Segment) error {
+	const op = "transport.storage.AddSegment"
+
+	querry := qb.Insert("task_segments").
+		Columns("id", "task_uuid", "start_time").
+		Values(uuid.NewString(), segment.TaskUUID, segment.StartTime)
+
+	return s.audited(ctx, op, taskChange(segment.TaskUUID, auditAddSegment), querry)
+}
+
+func (s *Storage) GetSegments(ctx context.Context, taskUUIDs []string) ([]entity.Segment, error) {
+	const op = "transport.storage.GetSegments"
+
+	querry := qb.Select("id", "task_uuid", "start_time", "end_time").
+		From("task_segments").
+		Where(sq.Eq{"task_uuid": taskUUIDs}).
+		OrderBy("start_time")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var segments []entity.Segment
+
+	for rows.Next() {
+		var (
+			segment entity.Segment
+			endTime *time.Time
+		)
+
+		err = rows.Scan(&segment.ID, &segment.TaskUUID, &segment.StartTime, &endTime)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if endTime != nil {
+			segment.EndTime = *endTime
+		}
+
+		segments = append(segments, segment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return segments, nil
+}
+
+func (s *Storage) UpdateSegment(ctx context.Context, segment entity.Segment) error {
+	const op = "transport.storage.UpdateSegment"
+
+	querry := qb.Update("task_segments").
+		Set("end_time", segment.EndTime).
+		Where(sq.Eq{"id": segment.ID})
+
+	return s.audited(ctx, op, taskChange(segment.TaskUUID, auditCloseSegment), querry)
+}
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// StartSyncRun records the start of a sync run. Runs left running for longer
+// than timeout are considered abandoned and closed as failed first. If another
+// run is still in progress it returns ErrNotFound, or ErrAlreadyExists if
+// the other run started at the same time.
+func (s *Storage) StartSyncRun(ctx context.Context, trigger string, timeout time.Duration) (entity.SyncRun, error) {
+	const op = "transport.storage.StartSyncRun"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return entity.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	abandonQuerry := qb.Update("sync_runs").
+		SetMap(map[string]interface{}{
+			"status":      entity.SyncFailed,
+			"finished_at": sq.Expr("now()"),
+			"last_error":  "run abandoned",
+		}).
+		Where(sq.And{
+			sq.Eq{"status": entity.SyncRunning},
+			sq.Lt{"started_at": time.Now().Add(-timeout)},
+		})
+
+	if err := s.execTx(ctx, tx, op, abandonQuerry); err != nil {
+		return entity.SyncRun{}, err
+	}
+
+	querry := qb.Insert("sync_runs").
+		Columns("trigger").
+		Select(sq.Select().
+			Column("?::text", trigger).
+			Where("NOT EXISTS (SELECT 1 FROM sync_runs WHERE status = ?)", entity.SyncRunning)).
+		Suffix("RETURNING id, started_at")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	run := entity.SyncRun{
+		Trigger: trigger,
+		Status:  entity.SyncRunning,
+	}
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return entity.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return run, nil
+}
+
+// GetSyncBatch returns up to limit active, enriched users after afterUserID
+// in user_id order.
+func (s *Storage) GetSyncBatch(ctx context.Context, afterUserID int, limit int) ([]entity.User, error) {
+	const op = "transport.storage.GetSyncBatch"
+
+	querry := qb.Select("user_id", "passport", "first_name", "last_name", "patronymic", "adress", "overridden_fields").
+		From("users").
+		Where(sq.And{
+			sq.Gt{"user_id": afterUserID},
+			sq.Eq{"status": entity.UserEnriched},
+			sq.Eq{"deleted_at": nil},
+		}).
+		OrderBy("user_id").
+		Limit(uint64(limit))
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var users []entity.User
+
+	for rows.Next() {
+		var user entity.User
+
+		err = rows.Scan(&user.UserID, &user.Passport, &user.Name, &user.Surmame, &user.Patronymic, &user.Adress, &user.Overridden)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// ApplySync writes changes found by sync run runID and records them in the
+// sync history. Fields overridden by hand since the user was read are left
+// out. It returns the number of changes applied.
+func (s *Storage) ApplySync(ctx context.Context, runID int64, userID int, changes []entity.FieldChange) (int, error) {
+	const op = "transport.storage.ApplySync"
+
+	tx, err := s.conn(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	lockQuerry := qb.Select("overridden_fields").
+		From("users").
+		Where(sq.Eq{"user_id": userID}).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := lockQuerry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var overridden []string
+
+	err = tx.QueryRow(ctx, sql, args...).Scan(&overridden)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	changes = slices.DeleteFunc(changes, func(c entity.FieldChange) bool {
+		return slices.Contains(overridden, c.Field)
+	})
+
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	values := make(map[string]interface{}, len(changes))
+
+	historyQuerry := qb.Insert("user_sync_history").
+		Columns("run_id", "user_id", "field", "old_value", "new_value")
+
+	for _, c := range changes {
+		values[c.Field] = c.New
+		historyQuerry = historyQuerry.Values(runID, userID, c.Field, c.Old, c.New)
+	}
+
+	querry := qb.Update("users").
+		SetMap(values).
+		Where(sq.Eq{"user_id": userID})
+
+	err = s.record(ctx, tx, op, userChange(userID, auditSync), func() error {
+		return s.execTx(ctx, tx, op, querry)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.execTx(ctx, tx, op, historyQuerry); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return len(changes), nil
+}
+
+func (s *Storage) FinishSyncRun(ctx context.Context, run entity.SyncRun) error {
+	const op = "transport.storage.FinishSyncRun"
+
+	querry := qb.Update("sync_runs").
+		SetMap(map[string]interface{}{
+			"status":        run.Status,
+			"finished_at":   sq.Expr("now()"),
+			"users_checked": run.Checked,
+			"users_updated": run.Updated,
+			"users_failed":  run.Failed,
+			"last_error":    run.LastError,
+		}).
+		Where(sq.Eq{"id": run.ID})
+
+	return s.exec(ctx, op, querry)
+}
+
+func (s *Storage) LastSyncRun(ctx context.Context) (entity.SyncRun, error) {
+	const op = "transport.storage.LastSyncRun"
+
+	querry := qb.Select("id", "trigger", "status", "started_at", "finished_at", "users_checked", "users_updated", "users_failed", "last_error").
+		From("sync_runs").
+		OrderBy("id DESC").
+		Limit(1)
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var run entity.SyncRun
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&run.ID, &run.Trigger, &run.Status, &run.StartedAt, &run.FinishedAt, &run.Checked, &run.Updated, &run.Failed, &run.LastError)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.SyncRun{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return run, nil
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// UserRole returns the stored role of an active user, an empty string when
+// none was assigned and ErrNotFound when the user is missing or archived.
+func (s *Storage) UserRole(ctx context.Context, userID int) (string, error) {
+	const op = "transport.storage.UserRole"
+
+	querry := qb.Select("COALESCE(r.role, '')").
+		From("users u").
+		LeftJoin("user_roles r ON r.user_id = u.user_id").
+		Where(sq.And{
+			sq.Eq{"u.user_id": userID},
+			sq.Eq{"u.deleted_at": nil},
+		})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var role string
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&role)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+func (s *Storage) SetUserRole(ctx context.Context, userID int, role string) error {
+	const op = "transport.storage.SetUserRole"
+
+	querry := qb.Insert("user_roles").
+		Columns("user_id", "role").
+		Values(userID, role).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET role = EXCLUDED.role")
+
+	return s.audited(ctx, op, change{
+		entity:    "user_roles",
+		entityID:  strconv.Itoa(userID),
+		operation: auditUpdate,
+		where:     sq.Eq{"user_id": userID},
+	}, querry)
+}
+
+// ManagesUser reports whether userID is a member of a team led by managerID.
+func (s *Storage) ManagesUser(ctx context.Context, managerID int, userID int) (bool, error) {
+	const op = "transport.storage.ManagesUser"
+
+	querry := qb.Select("1").
+		Prefix("SELECT EXISTS (").
+		From("team_members m").
+		Join("teams t ON t.id = m.team_id").
+		Where(sq.And{
+			sq.Eq{"t.manager_id": managerID},
+			sq.Eq{"m.user_id": userID},
+		}).
+		Suffix(")")
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var manages bool
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&manages)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return manages, nil
+}
+
+func (s *Storage) AddTeam(ctx context.Context, team entity.Team) (int, error) {
+	const op = "transport.storage.AddTeam"
+
+	querry := qb.Insert("teams").
+		Columns("name", "manager_id").
+		Values(team.Name, nullableID(team.ManagerID))
+
+	return s.create(ctx, op, "teams", "id", querry)
+}
+
+func (s *Storage) GetTeam(ctx context.Context, id int) (entity.Team, error) {
+	const op = "transport.storage.GetTeam"
+
+	querry := teamsQuerry().
+		Where(sq.Eq{"t.id": id})
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Team{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var team entity.Team
+
+	err = s.conn(ctx).QueryRow(ctx, sql, args...).Scan(&team.ID, &team.Name, &team.ManagerID, &team.CreatedAt, &team.Members)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return entity.Team{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return team, nil
+}
+
+func (s *Storage) GetTeams(ctx context.Context, managerID int) ([]entity.Team, error) {
+	const op = "transport.storage.GetTeams"
+
+	querry := teamsQuerry().
+		OrderBy("t.name")
+
+	if managerID != 0 {
+		querry = querry.Where(sq.Eq{"t.manager_id": managerID})
+	}
+
+	sql, args, err := querry.ToSql()
+	if err != nil {
+		s.logger.Debug("could not convert query to sql",
+			slog.String("description", op),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		s.logger.Debug("sql error",
+			slog.String("description", op),
+			slog.String("sql", sql),
+			slog.Any("args", args),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer rows.Close()
+
+	var teams []entity.Team
+
+	for rows.Next() {
+		var team entity.Team
+
+		err = rows.Scan(&team.ID, &team.Name, &team.ManagerID, &team.CreatedAt, &team.Members)
+		if err != nil {
+			s.logger.Debug("could not scan row",
+				slog.String("description", op),
+				slog.String("error", err.Error()),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+func (s *Storage) UpdateTeam(ctx context.Context, team entity.Team) error {
+	const op = "transport.storage.UpdateTeam"
+
+	querry := qb.Update("teams").
+		SetMap(map[string]interface{}{
+			"name":       team.Name,
+			"manager_id": nullableID(team.ManagerID),
+		}).
+		Where(sq.Eq{"id": team.ID})
+
+	return s.audited(ctx, op, change{
+		entity:    "teams",
+		entityID:  strconv.Itoa(team.ID),
+		operation: auditUpdate,
+		where:     sq.Eq{"id": team.ID},
+	}, querry)
+}
+
+func (s *Storage) DeleteTeam(ctx context.Context, id int) error {
+	const op = "transport.storage.DeleteTeam"
+
+	querry := qb.Delete("teams").
+		Where(sq.Eq{"id": id})
+
+	return s.audited(ctx, op, change{
+		entity:    "teams",
+		entityID:  strconv.Itoa(id),
+		operation: auditDelete,
+		where:     sq.Eq{"id": id},
+	}, querry)
+}
+
+// AddTeamMember is idempotent, adding an existing member changes nothing and
+// leaves no audit record.
+func (s *Storage) AddTeamMember(ctx context.Context, teamID int, userID int) error {
+	const op = "transport.storage.AddTeamMember"
+
+	querry := qb.Insert("team_members").
+		Columns("team_id", "user_id").
+		Values(teamID, userID).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	err := s.audited(ctx, op, teamMemberChange(teamID, userID, auditCreate), querry)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *Storage) DeleteTeamMember(ctx context.Context, teamID int, userID int) error {
+	const op = "transport.storage.DeleteTeamMember"
+
+	querry := qb.Delete("team_members").
+		Where(sq.Eq{
+			"team_id": teamID,
+			"user_id": userID,
+		})
+
+	return s.audited(ctx, op, teamMemberChange(teamID, userID, auditDelete), querry)
+}
+
+func teamMemberChange(teamID int, userID int, operation string) change {
+	return change{
+		entity:    "team_members",
+		entityID:  strconv.Itoa(teamID) + ":" + strconv.Itoa(userID),
+		operation: operation,
+		where: sq.Eq{
+			"team_id": teamID,
+			"user_id": userID,
+		},
+	}
+}
+
+func teamsQuerry() sq.SelectBuilder {
+	return qb.Select("t.id", "t.name", "COALESCE(t.manager_id, 0)", "t.created_at").
+		Column("COALESCE(array_agg(m.user_id ORDER BY m.user_id) FILTER (WHERE m.user_id IS NOT NULL), '{}')").
+		From("teams t").
+		LeftJoin("team_members m ON m.team_id = t.id").
+		GroupBy("t.id")
+}
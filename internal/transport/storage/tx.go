@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/njslxve/time-tracker-service/internal/model/entity"
+)
+
+// Postgres aborts one of the transactions of a serialization failure or a
+// deadlock; running it again is expected to succeed.
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+const txRetryBackoff = 20 * time.Millisecond
+
+var ErrInvalidIsolation = errors.New("isolation level must be one of read committed, repeatable read, serializable")
+
+type txKey struct{}
+
+// querier is what statements run on: the pool, or the transaction a unit of
+// work put in the context. Begin on a transaction starts a savepoint, so
+// methods with transactions of their own can take part in a unit of work.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+func (s *Storage) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return translating{q: tx}
+	}
+
+	return translating{q: s.db}
+}
+
+// WithTx runs fn as one unit of work: every storage call made with the
+// context fn gets shares a single transaction, committed when fn returns nil
+// and rolled back otherwise. A transaction aborted by a serialization failure
+// or a deadlock is run again up to opts.Retries times, so fn must not have
+// effects outside the database. Called within a unit of work, WithTx joins it.
+func (s *Storage) WithTx(ctx context.Context, opts entity.TxOptions, fn func(context.Context) error) error {
+	const op = "transport.storage.WithTx"
+
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	txOpts := pgx.TxOptions{}
+
+	switch opts.Isolation {
+	case "":
+	case entity.IsolationReadCommitted:
+		txOpts.IsoLevel = pgx.ReadCommitted
+	case entity.IsolationRepeatableRead:
+		txOpts.IsoLevel = pgx.RepeatableRead
+	case entity.IsolationSerializable:
+		txOpts.IsoLevel = pgx.Serializable
+	default:
+		return fmt.Errorf("%s: %w", op, ErrInvalidIsolation)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, txOpts, fn)
+		if err == nil || attempt > opts.Retries || !retryable(err) {
+			return err
+		}
+
+		s.logger.Debug("transaction retry",
+			slog.String("description", op),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()),
+		)
+
+		backoff := time.Duration(attempt) * txRetryBackoff
+		backoff += rand.N(backoff)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (s *Storage) runTx(ctx context.Context, txOpts pgx.TxOptions, fn func(context.Context) error) error {
+	const op = "transport.storage.WithTx"
+
+	tx, err := s.db.BeginTx(ctx, txOpts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, translate(err))
+	}
+
+	return nil
+}
+
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+}
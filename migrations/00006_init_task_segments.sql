@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS task_segments(
+  id uuid PRIMARY KEY,
+  task_uuid uuid NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  start_time timestamptz NOT NULL,
+  end_time timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_segments_task_uuid ON task_segments(task_uuid);
+
+INSERT INTO task_segments(id, task_uuid, start_time, end_time)
+SELECT gen_random_uuid(), id, start_time, end_time FROM tasks;
+
+-- +goose Down
+DROP TABLE task_segments;
\ No newline at end of file
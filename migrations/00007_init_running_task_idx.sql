@@ -0,0 +1,25 @@
+-- +goose Up
+WITH stale AS (
+  SELECT id FROM (
+    SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY start_time DESC) AS rn
+    FROM tasks
+    WHERE end_time IS NULL
+  ) running
+  WHERE rn > 1
+), closed AS (
+  UPDATE task_segments SET end_time = now()
+  WHERE end_time IS NULL AND task_uuid IN (SELECT id FROM stale)
+)
+UPDATE tasks t SET
+  end_time = now(),
+  duration = (
+    SELECT COALESCE(floor(extract(epoch FROM sum(COALESCE(s.end_time, now()) - s.start_time)) / 60), 0)
+    FROM task_segments s
+    WHERE s.task_uuid = t.id
+  )
+WHERE t.id IN (SELECT id FROM stale);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_running_user_id ON tasks(user_id) WHERE end_time IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_running_user_id;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_start_time ON tasks(user_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_task_segments_start_time ON task_segments(start_time);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_task_segments_start_time;
+DROP INDEX IF EXISTS idx_tasks_user_id_start_time;
\ No newline at end of file
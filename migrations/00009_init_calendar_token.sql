@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_token TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS calendar_token;
\ No newline at end of file
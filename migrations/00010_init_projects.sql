@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS projects(
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS task_definitions(
+  task_id TEXT PRIMARY KEY,
+  project_id integer REFERENCES projects(id) ON DELETE SET NULL,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_definitions_project_id ON task_definitions(project_id);
+
+INSERT INTO task_definitions(task_id, title)
+SELECT DISTINCT task_id, task_id FROM tasks
+ON CONFLICT (task_id) DO NOTHING;
+
+ALTER TABLE tasks
+  ADD CONSTRAINT tasks_task_id_fkey FOREIGN KEY (task_id) REFERENCES task_definitions(task_id) ON UPDATE CASCADE;
+
+-- +goose Down
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_task_id_fkey;
+DROP TABLE task_definitions;
+DROP TABLE projects;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS info_cache (
+    passport TEXT PRIMARY KEY,
+    first_name TEXT NOT NULL DEFAULT '',
+    last_name TEXT NOT NULL DEFAULT '',
+    patronymic TEXT NOT NULL DEFAULT '',
+    adress TEXT NOT NULL DEFAULT '',
+    not_found BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_info_cache_expires_at ON info_cache(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS info_cache;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'enriched'
+    CHECK (status IN ('pending_enrichment', 'enriched', 'enrichment_failed'));
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_user_id ON enrichment_jobs(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_pending ON enrichment_jobs(run_at) WHERE status IN ('queued', 'running');
+
+-- +goose Down
+DROP TABLE IF EXISTS enrichment_jobs;
+ALTER TABLE users DROP COLUMN IF EXISTS status;
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS overridden_fields TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id BIGSERIAL PRIMARY KEY,
+    trigger TEXT NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'done', 'failed')),
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    users_checked INT NOT NULL DEFAULT 0,
+    users_updated INT NOT NULL DEFAULT 0,
+    users_failed INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sync_runs_running ON sync_runs((status)) WHERE status = 'running';
+
+CREATE TABLE IF NOT EXISTS user_sync_history (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES sync_runs(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sync_history_user_id ON user_sync_history(user_id, changed_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_sync_history;
+DROP TABLE IF EXISTS sync_runs;
+ALTER TABLE users DROP COLUMN IF EXISTS overridden_fields;
\ No newline at end of file
@@ -0,0 +1,48 @@
+-- +goose Up
+-- Legacy passports that normalize to a passport some other user already has
+-- keep their old value and are listed here for someone to resolve by hand.
+CREATE TABLE passport_normalization_conflicts (
+    user_id INT PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    passport TEXT NOT NULL,
+    normalized TEXT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TEMPORARY TABLE passport_normalization ON COMMIT DROP AS
+SELECT user_id,
+       passport,
+       normalized,
+       row_number() OVER (PARTITION BY normalized ORDER BY user_id) AS rank,
+       EXISTS (
+           SELECT 1 FROM users AS o
+           WHERE o.passport = n.normalized
+             AND o.user_id <> n.user_id
+       ) AS taken
+FROM (
+    SELECT user_id,
+           passport,
+           substr(digits, 1, 4) || ' ' || substr(digits, 5) AS normalized
+    FROM (
+        SELECT user_id, passport, regexp_replace(passport, '[[:space:]-]', '', 'g') AS digits
+        FROM users
+    ) AS d
+    WHERE digits ~ '^[0-9]{10}$'
+) AS n
+WHERE passport <> normalized;
+
+INSERT INTO passport_normalization_conflicts (user_id, passport, normalized)
+SELECT user_id, passport, normalized
+FROM passport_normalization
+WHERE rank > 1 OR taken;
+
+UPDATE users AS u
+SET passport = n.normalized
+FROM passport_normalization AS n
+WHERE u.user_id = n.user_id
+  AND n.rank = 1
+  AND NOT n.taken;
+
+DELETE FROM info_cache WHERE passport !~ '^[0-9]{4} [0-9]{6}$';
+
+-- +goose Down
+DROP TABLE passport_normalization_conflicts;
\ No newline at end of file
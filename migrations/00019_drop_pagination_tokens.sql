@@ -0,0 +1,12 @@
+-- +goose Up
+DROP TABLE IF EXISTS pagination_tokens;
+
+-- +goose Down
+CREATE TABLE IF NOT EXISTS pagination_tokens(
+  token TEXT PRIMARY KEY,
+  old_limit integer NOT NULL,
+  filter_params JSONB,
+  is_alive boolean DEFAULT true,
+  created_at timestamptz,
+  ttl INTERVAL DEFAULT '1 day'
+);
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_first_name_trgm ON users USING GIN (first_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_last_name_trgm ON users USING GIN (last_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_patronymic_trgm ON users USING GIN (patronymic gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_adress_trgm ON users USING GIN (adress gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_users_search_fts ON users
+    USING GIN (to_tsvector('simple', first_name || ' ' || last_name || ' ' || COALESCE(patronymic, '') || ' ' || adress));
+CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users
+    USING GIN ((first_name || ' ' || last_name || ' ' || COALESCE(patronymic, '') || ' ' || adress) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_users_passport_pattern ON users (passport text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_first_name_user_id ON users (first_name, user_id);
+CREATE INDEX IF NOT EXISTS idx_users_last_name_user_id ON users (last_name, user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_last_name_user_id;
+DROP INDEX IF EXISTS idx_users_first_name_user_id;
+DROP INDEX IF EXISTS idx_users_passport_pattern;
+DROP INDEX IF EXISTS idx_users_search_trgm;
+DROP INDEX IF EXISTS idx_users_search_fts;
+DROP INDEX IF EXISTS idx_users_adress_trgm;
+DROP INDEX IF EXISTS idx_users_patronymic_trgm;
+DROP INDEX IF EXISTS idx_users_last_name_trgm;
+DROP INDEX IF EXISTS idx_users_first_name_trgm;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Entries stay append-only, except that a transaction which sets
+-- audit_log.redact may rewrite their state, as purging a user does to remove
+-- personal data. Which entry it was, when, by whom and what it did stays.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND current_setting('audit_log.redact', true) = 'on'
+        AND (NEW.id, NEW.occurred_at, NEW.actor, NEW.actor_user_id, NEW.request_id, NEW.entity, NEW.entity_id, NEW.operation)
+            IS NOT DISTINCT FROM (OLD.id, OLD.occurred_at, OLD.actor, OLD.actor_user_id, OLD.request_id, OLD.entity, OLD.entity_id, OLD.operation)
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
\ No newline at end of file
@@ -4,22 +4,41 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/njslxve/time-tracker-service/internal/config"
 )
 
-func NewClient(cfg *config.Config) (*pgx.Conn, error) {
+// NewClient opens a connection pool and checks that the database answers.
+// Idle connections are pinged every cfg.DBHealthCheckPeriod and broken ones
+// are replaced.
+func NewClient(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
 	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
 
-	db, err := pgx.Connect(context.Background(), dsn)
+	poolCfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping(context.Background())
+	poolCfg.MaxConns = cfg.DBMaxConns
+	poolCfg.MinConns = cfg.DBMinConns
+	poolCfg.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolCfg.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+	poolCfg.HealthCheckPeriod = cfg.DBHealthCheckPeriod
+	poolCfg.ConnConfig.ConnectTimeout = cfg.DBConnectTimeout
+
+	db, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, err
 	}
 
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.DBConnectTimeout)
+	defer cancel()
+
+	if err := db.Ping(pingCtx); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
 	return db, nil
 }
@@ -0,0 +1,124 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	timeFormat = "20060102T150405Z"
+	lineLimit  = 75
+)
+
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	Summary     string
+	Description string
+	Status      string
+}
+
+// Writer streams a VCALENDAR, one VEVENT at a time.
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer, name string) (*Writer, error) {
+	cw := &Writer{w: bufio.NewWriter(w)}
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//time-tracker-service//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escape(name),
+	}
+
+	for _, line := range lines {
+		if err := cw.writeLine(line); err != nil {
+			return nil, err
+		}
+	}
+
+	return cw, nil
+}
+
+func (c *Writer) WriteEvent(e Event) error {
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + escape(e.UID),
+		"DTSTAMP:" + e.Stamp.UTC().Format(timeFormat),
+		"DTSTART:" + e.Start.UTC().Format(timeFormat),
+		"DTEND:" + e.End.UTC().Format(timeFormat),
+		"SUMMARY:" + escape(e.Summary),
+	}
+
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escape(e.Description))
+	}
+
+	if e.Status != "" {
+		lines = append(lines, "STATUS:"+e.Status)
+	}
+
+	lines = append(lines, "END:VEVENT")
+
+	for _, line := range lines {
+		if err := c.writeLine(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Writer) Close() error {
+	if err := c.writeLine("END:VCALENDAR"); err != nil {
+		return err
+	}
+
+	return c.w.Flush()
+}
+
+func (c *Writer) writeLine(line string) error {
+	limit := lineLimit
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8Start(line[cut]) {
+			cut--
+		}
+
+		if _, err := c.w.WriteString(line[:cut] + "\r\n "); err != nil {
+			return err
+		}
+
+		line = line[cut:]
+		limit = lineLimit - 1
+	}
+
+	_, err := c.w.WriteString(line + "\r\n")
+
+	return err
+}
+
+func utf8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,167 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "plain text", want: "plain text"},
+		{input: "a;b,c", want: `a\;b\,c`},
+		{input: `back\slash`, want: `back\\slash`},
+		{input: "two\nlines", want: `two\nlines`},
+		{input: "two\r\nlines", want: `two\nlines`},
+		{input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := escape(tt.input); got != tt.want {
+				t.Errorf("escape(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short", line: "SUMMARY:short"},
+		{name: "exactly the limit", line: strings.Repeat("a", lineLimit)},
+		{name: "one over the limit", line: strings.Repeat("a", lineLimit+1)},
+		{name: "several folds", line: "DESCRIPTION:" + strings.Repeat("0123456789", 20)},
+		{name: "multibyte", line: "SUMMARY:" + strings.Repeat("время ", 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			c := &Writer{w: bufio.NewWriter(&buf)}
+
+			if err := c.writeLine(tt.line); err != nil {
+				t.Fatalf("writeLine error = %v", err)
+			}
+
+			if err := c.w.Flush(); err != nil {
+				t.Fatalf("Flush error = %v", err)
+			}
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", out)
+			}
+
+			for _, physical := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+				if len(physical) > lineLimit {
+					t.Errorf("line %q is %d octets long, want at most %d", physical, len(physical), lineLimit)
+				}
+
+				if !utf8.ValidString(physical) {
+					t.Errorf("line %q splits a character", physical)
+				}
+			}
+
+			if got := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); got != tt.line {
+				t.Errorf("unfolded line = %q, want %q", got, tt.line)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	stamp := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{
+			name: "all fields",
+			event: Event{
+				UID:         "1@time-tracker",
+				Start:       start,
+				End:         start.Add(90 * time.Minute),
+				Stamp:       stamp,
+				Summary:     "Review, planning",
+				Description: "Duration: 1h30m",
+				Status:      "CONFIRMED",
+			},
+			want: []string{
+				"BEGIN:VEVENT",
+				"UID:1@time-tracker",
+				"DTSTAMP:20240302T000000Z",
+				"DTSTART:20240301T060000Z",
+				"DTEND:20240301T073000Z",
+				`SUMMARY:Review\, planning`,
+				"DESCRIPTION:Duration: 1h30m",
+				"STATUS:CONFIRMED",
+				"END:VEVENT",
+			},
+		},
+		{
+			name: "optional fields left out",
+			event: Event{
+				UID:     "2@time-tracker",
+				Start:   start,
+				End:     start,
+				Stamp:   stamp,
+				Summary: "Task",
+			},
+			want: []string{
+				"BEGIN:VEVENT",
+				"UID:2@time-tracker",
+				"DTSTAMP:20240302T000000Z",
+				"DTSTART:20240301T060000Z",
+				"DTEND:20240301T060000Z",
+				"SUMMARY:Task",
+				"END:VEVENT",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			cw, err := NewWriter(&buf, "Time tracker; user 1")
+			if err != nil {
+				t.Fatalf("NewWriter error = %v", err)
+			}
+
+			if err := cw.WriteEvent(tt.event); err != nil {
+				t.Fatalf("WriteEvent error = %v", err)
+			}
+
+			if err := cw.Close(); err != nil {
+				t.Fatalf("Close error = %v", err)
+			}
+
+			want := append([]string{
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:-//time-tracker-service//EN",
+				"CALSCALE:GREGORIAN",
+				"METHOD:PUBLISH",
+				`X-WR-CALNAME:Time tracker\; user 1`,
+			}, tt.want...)
+			want = append(want, "END:VCALENDAR")
+
+			if got := buf.String(); got != strings.Join(want, "\r\n")+"\r\n" {
+				t.Errorf("calendar =\n%s\nwant\n%s", got, strings.Join(want, "\r\n"))
+			}
+		})
+	}
+}
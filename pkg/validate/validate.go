@@ -0,0 +1,209 @@
+// Package validate checks structs against the rules in their validate tags:
+//
+//	type Request struct {
+//		UserID int    `json:"user_id" validate:"required,gt=0"`
+//		Name   string `json:"name,omitempty" validate:"omitempty,max=100"`
+//	}
+//
+// Rules are separated by commas and checked in order; the first one a field
+// breaks is reported. Fields are named as in their json tag.
+//
+//	required  the field must not be the zero value
+//	omitempty the other rules are skipped for the zero value
+//	gt=N      a number must be greater than N
+//	min=N     a number must be at least N, a string at least N characters long
+//	max=N     a number must be at most N, a string at most N characters long
+//
+//	required_without=F the field must be set when the field F is not
+//	gtfield=F          a number or time must be greater than the field F,
+//	                   unless F is not set
+//
+// Fields named in rules are Go field names. Pointers are followed. Any other
+// rule must be registered with Validator.Rule.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Errors lists every field that broke a rule.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for _, field := range e {
+		fields = append(fields, field.Field+" "+field.Message)
+	}
+
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// RuleFunc checks a field value. The error it returns becomes the message
+// for the field.
+type RuleFunc func(value any) error
+
+type Validator struct {
+	rules map[string]RuleFunc
+}
+
+func New() *Validator {
+	return &Validator{
+		rules: make(map[string]RuleFunc),
+	}
+}
+
+// Rule registers the rule name, to be used in tags like the built-in ones.
+func (v *Validator) Rule(name string, fn RuleFunc) {
+	v.rules[name] = fn
+}
+
+// Struct validates s, a struct or a pointer to one. It returns Errors if any
+// field breaks a rule and panics on tags it does not understand, as those are
+// mistakes in the code rather than in the input.
+func (v *Validator) Struct(s any) error {
+	value := reflect.Indirect(reflect.ValueOf(s))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: %T is not a struct", s))
+	}
+
+	var errs Errors
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		if message := v.field(value, value.Field(i), tag); message != "" {
+			errs = append(errs, FieldError{
+				Field:   fieldName(field),
+				Message: message,
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (v *Validator) field(parent reflect.Value, value reflect.Value, tag string) string {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			if value.IsZero() {
+				return "is required"
+			}
+		case "required_without":
+			other, otherName := sibling(parent, param)
+			if value.IsZero() && other.IsZero() {
+				return "is required without " + otherName
+			}
+		case "gtfield":
+			other, otherName := sibling(parent, param)
+			if other.IsZero() {
+				continue
+			}
+
+			if after, ok := reflect.Indirect(value).Interface().(time.Time); ok {
+				if !after.After(reflect.Indirect(other).Interface().(time.Time)) {
+					return "must be after " + otherName
+				}
+			} else if number(reflect.Indirect(value)) <= number(reflect.Indirect(other)) {
+				return "must be greater than " + otherName
+			}
+		case "omitempty":
+			if value.IsZero() {
+				return ""
+			}
+		case "gt":
+			if number(value) <= limit(param) {
+				return "must be greater than " + param
+			}
+		case "min":
+			if value.Kind() == reflect.String {
+				if utf8.RuneCountInString(value.String()) < int(limit(param)) {
+					return fmt.Sprintf("must be at least %s characters long", param)
+				}
+			} else if number(value) < limit(param) {
+				return "must be at least " + param
+			}
+		case "max":
+			if value.Kind() == reflect.String {
+				if utf8.RuneCountInString(value.String()) > int(limit(param)) {
+					return fmt.Sprintf("must be at most %s characters long", param)
+				}
+			} else if number(value) > limit(param) {
+				return "must be at most " + param
+			}
+		default:
+			fn, ok := v.rules[name]
+			if !ok {
+				panic("validate: unknown rule " + name)
+			}
+
+			if err := fn(value.Interface()); err != nil {
+				return err.Error()
+			}
+		}
+	}
+
+	return ""
+}
+
+// sibling returns the field of parent called name and how errors name it.
+func sibling(parent reflect.Value, name string) (reflect.Value, string) {
+	field, ok := parent.Type().FieldByName(name)
+	if !ok {
+		panic("validate: unknown field " + name)
+	}
+
+	return parent.FieldByIndex(field.Index), fieldName(field)
+}
+
+func number(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	default:
+		panic("validate: " + value.Kind().String() + " is not a number")
+	}
+}
+
+func limit(param string) float64 {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("validate: invalid rule parameter " + param)
+	}
+
+	return n
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
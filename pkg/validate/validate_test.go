@@ -0,0 +1,276 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type request struct {
+	UserID   int     `json:"user_id" validate:"required,gt=0"`
+	Name     string  `json:"name,omitempty" validate:"omitempty,min=2,max=5"`
+	Hours    float64 `json:"hours" validate:"min=0,max=24"`
+	Code     string  `validate:"code"`
+	Count    uint    `json:"-" validate:"max=3"`
+	Note     string  `json:"note"`
+	internal string  `validate:"required"`
+}
+
+func newTestValidator() *Validator {
+	v := New()
+
+	v.Rule("code", func(value any) error {
+		if s, _ := value.(string); !strings.HasPrefix(s, "c-") {
+			return errors.New("must start with c-")
+		}
+
+		return nil
+	})
+
+	return v
+}
+
+func TestStruct(t *testing.T) {
+	valid := request{UserID: 1, Name: "Ann", Hours: 8, Code: "c-1", Count: 3}
+
+	tests := []struct {
+		name  string
+		input any
+		want  Errors
+	}{
+		{
+			name:  "valid",
+			input: valid,
+		},
+		{
+			name:  "pointer",
+			input: &valid,
+		},
+		{
+			name:  "empty optional field",
+			input: request{UserID: 1, Code: "c-1"},
+		},
+		{
+			name:  "missing required field",
+			input: request{Code: "c-1"},
+			want:  Errors{{Field: "user_id", Message: "is required"}},
+		},
+		{
+			name:  "not greater",
+			input: request{UserID: -1, Code: "c-1"},
+			want:  Errors{{Field: "user_id", Message: "must be greater than 0"}},
+		},
+		{
+			name:  "string too short",
+			input: request{UserID: 1, Name: "A", Code: "c-1"},
+			want:  Errors{{Field: "name", Message: "must be at least 2 characters long"}},
+		},
+		{
+			name:  "string too long",
+			input: request{UserID: 1, Name: "Annabel", Code: "c-1"},
+			want:  Errors{{Field: "name", Message: "must be at most 5 characters long"}},
+		},
+		{
+			name:  "characters not bytes",
+			input: request{UserID: 1, Name: "Анна", Code: "c-1"},
+		},
+		{
+			name:  "number out of range",
+			input: request{UserID: 1, Hours: 25, Code: "c-1", Count: 4},
+			want: Errors{
+				{Field: "hours", Message: "must be at most 24"},
+				{Field: "Count", Message: "must be at most 3"},
+			},
+		},
+		{
+			name:  "negative number",
+			input: request{UserID: 1, Hours: -1, Code: "c-1"},
+			want:  Errors{{Field: "hours", Message: "must be at least 0"}},
+		},
+		{
+			name:  "registered rule",
+			input: request{UserID: 1, Code: "x-1"},
+			want:  Errors{{Field: "Code", Message: "must start with c-"}},
+		},
+		{
+			name:  "every broken field",
+			input: request{Name: "A", Code: "x"},
+			want: Errors{
+				{Field: "user_id", Message: "is required"},
+				{Field: "name", Message: "must be at least 2 characters long"},
+				{Field: "Code", Message: "must start with c-"},
+			},
+		},
+	}
+
+	v := newTestValidator()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.input)
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct error = %v", err)
+				}
+
+				return
+			}
+
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("Struct error = %v, want Errors", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type interval struct {
+	Start *time.Time `json:"start" validate:"required_without=End"`
+	End   *time.Time `json:"end" validate:"omitempty,gtfield=Start"`
+	From  int        `json:"from"`
+	To    int        `json:"to" validate:"omitempty,gtfield=From"`
+}
+
+func TestStructCrossField(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	after := start.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		input interval
+		want  Errors
+	}{
+		{
+			name:  "start only",
+			input: interval{Start: &start},
+		},
+		{
+			name:  "end only",
+			input: interval{End: &start},
+		},
+		{
+			name:  "end after start",
+			input: interval{Start: &start, End: &after},
+		},
+		{
+			name:  "neither",
+			input: interval{},
+			want:  Errors{{Field: "start", Message: "is required without end"}},
+		},
+		{
+			name:  "end before start",
+			input: interval{Start: &start, End: &before},
+			want:  Errors{{Field: "end", Message: "must be after start"}},
+		},
+		{
+			name:  "end at start",
+			input: interval{Start: &start, End: &start},
+			want:  Errors{{Field: "end", Message: "must be after start"}},
+		},
+		{
+			name:  "number greater",
+			input: interval{Start: &start, From: 1, To: 2},
+		},
+		{
+			name:  "number not greater",
+			input: interval{Start: &start, From: 2, To: 2},
+			want:  Errors{{Field: "to", Message: "must be greater than from"}},
+		},
+	}
+
+	v := New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.input)
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct error = %v", err)
+				}
+
+				return
+			}
+
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("Struct error = %v, want Errors", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{
+			name:  "not a struct",
+			input: "text",
+		},
+		{
+			name: "unknown rule",
+			input: struct {
+				Name string `validate:"email"`
+			}{},
+		},
+		{
+			name: "invalid parameter",
+			input: struct {
+				Age int `validate:"max=ten"`
+			}{},
+		},
+		{
+			name: "unknown sibling",
+			input: struct {
+				End int `validate:"gtfield=Start"`
+			}{},
+		},
+		{
+			name: "number rule on a bool",
+			input: struct {
+				Active bool `validate:"gt=0"`
+			}{},
+		},
+	}
+
+	v := New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Struct(%+v) did not panic", tt.input)
+				}
+			}()
+
+			v.Struct(tt.input)
+		})
+	}
+}
+
+func TestErrorsError(t *testing.T) {
+	err := Errors{
+		{Field: "user_id", Message: "is required"},
+		{Field: "name", Message: "must be at most 5 characters long"},
+	}
+
+	want := "validation failed: user_id is required, name must be at most 5 characters long"
+
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}